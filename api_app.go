@@ -167,8 +167,8 @@ type ApplicationPreferences struct {
 	ListenPort                         *int                    `json:"listen_port,omitempty"`                            // Port for incoming connections
 	UPnP                               *bool                   `json:"upnp,omitempty"`                                   // True if UPnP/NAT-PMP is enabled
 	RandomPort                         *bool                   `json:"random_port,omitempty"`                            // True if the port is randomly selected
-	DlLimit                            *int                    `json:"dl_limit,omitempty"`                               // Global download speed limit in KiB/s; -1 means no limit is applied
-	UpLimit                            *int                    `json:"up_limit,omitempty"`                               // Global upload speed limit in KiB/s; -1 means no limit is applied
+	DlLimit                            *int                    `json:"dl_limit,omitempty"`                               // Global download speed limit in bytes/s; 0 means no limit is applied
+	UpLimit                            *int                    `json:"up_limit,omitempty"`                               // Global upload speed limit in bytes/s; 0 means no limit is applied
 	MaxConnec                          *int                    `json:"max_connec,omitempty"`                             // Maximum global number of simultaneous connections
 	MaxConnecPerTorrent                *int                    `json:"max_connec_per_torrent,omitempty"`                 // Maximum number of simultaneous connections per torrent
 	MaxUploads                         *int                    `json:"max_uploads,omitempty"`                            // Maximum number of upload slots
@@ -179,8 +179,8 @@ type ApplicationPreferences struct {
 	LimitUtpRate                       *bool                   `json:"limit_utp_rate,omitempty"`                         // True if [du]l_limit should be applied to uTP connections; this option is only available in qBittorent built against libtorrent version 0.16.X and higher
 	LimitTCPOverhead                   *bool                   `json:"limit_tcp_overhead,omitempty"`                     // True if [du]l_limit should be applied to estimated TCP overhead (service data: e.g. packet headers)
 	LimitLanPeers                      *bool                   `json:"limit_lan_peers,omitempty"`                        // True if [du]l_limit should be applied to peers on the LAN
-	AltDlLimit                         *int                    `json:"alt_dl_limit,omitempty"`                           // Alternative global download speed limit in KiB/s
-	AltUpLimit                         *int                    `json:"alt_up_limit,omitempty"`                           // Alternative global upload speed limit in KiB/s
+	AltDlLimit                         *int                    `json:"alt_dl_limit,omitempty"`                           // Alternative global download speed limit in bytes/s; 0 means no limit is applied
+	AltUpLimit                         *int                    `json:"alt_up_limit,omitempty"`                           // Alternative global upload speed limit in bytes/s; 0 means no limit is applied
 	SchedulerEnabled                   *bool                   `json:"scheduler_enabled,omitempty"`                      // True if alternative limits should be applied according to schedule
 	ScheduleFromHour                   *int                    `json:"schedule_from_hour,omitempty"`                     // Scheduler starting hour
 	ScheduleFromMin                    *int                    `json:"schedule_from_min,omitempty"`                      // Scheduler starting minute
@@ -44,6 +44,8 @@ func (c *Client) Login(ctx context.Context) (err error) {
 		err = fmt.Errorf("unexpected server response: %s", output)
 		return
 	}
+	// the server may have been upgraded since the last session
+	c.resetAPIVersion()
 	return
 }
 
@@ -3,32 +3,519 @@ package qbtapi
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 const rssAPIName = "rss"
 
 // RSSAutoDownloadingRule represents an auto-downloading rule for RSS feeds.
+// Keys unknown to this model are kept and sent back as is, allowing to safely update rules created by newer versions.
 type RSSAutoDownloadingRule struct {
-	Enabled                   bool     `json:"enabled"`
-	MustContain               string   `json:"mustContain"`
-	MustNotContain            string   `json:"mustNotContain"`
-	UseRegex                  bool     `json:"useRegex"`
-	EpisodeFilter             string   `json:"episodeFilter"`
-	SmartFilter               bool     `json:"smartFilter"`
-	PreviouslyMatchedEpisodes []string `json:"previouslyMatchedEpisodes"`
-	AffectedFeeds             []string `json:"affectedFeeds"`
-	IgnoreDays                int      `json:"ignoreDays"`
-	LastMatch                 string   `json:"lastMatch"`
-	AddPaused                 bool     `json:"addPaused"`
-	AssignedCategory          string   `json:"assignedCategory"`
-	SavePath                  string   `json:"savePath"`
-}
-
-// RSSItems represents the tree structure of RSS feeds and folders returned by GetAllRSSItems.
-// Values can be either string (feed URL) or RSSItems (subfolder).
-type RSSItems map[string]interface{}
+	Enabled                   bool              `json:"enabled"`
+	MustContain               string            `json:"mustContain"`
+	MustNotContain            string            `json:"mustNotContain"`
+	UseRegex                  bool              `json:"useRegex"`
+	EpisodeFilter             string            `json:"episodeFilter"`
+	SmartFilter               bool              `json:"smartFilter"`
+	PreviouslyMatchedEpisodes []string          `json:"previouslyMatchedEpisodes"`
+	AffectedFeeds             []string          `json:"affectedFeeds"` // URLs of the feeds the rule applies to
+	IgnoreDays                int               `json:"ignoreDays"`
+	LastMatch                 string            `json:"lastMatch"` // RFC 2822 date, empty if the rule never matched
+	Priority                  int               `json:"priority"`  // Rules with a higher priority are evaluated first
+	TorrentParams             *RSSTorrentParams `json:"torrentParams,omitempty"`
+	// Legacy torrent parameters, superseded by TorrentParams on qBittorrent 5 (nil means default)
+	AddPaused            *bool                 `json:"addPaused"`
+	TorrentContentLayout *TorrentContentLayout `json:"torrentContentLayout"`
+	AssignedCategory     string                `json:"assignedCategory"`
+	SavePath             string                `json:"savePath"`
+	// keys unknown to this model, by JSON key
+	unknownFields map[string]json.RawMessage
+}
+
+func (rule *RSSAutoDownloadingRule) UnmarshalJSON(data []byte) (err error) {
+	type mask RSSAutoDownloadingRule
+	if err = json.Unmarshal(data, (*mask)(rule)); err != nil {
+		return
+	}
+	rule.unknownFields, err = extractUnknownFields(data, *rule)
+	return
+}
+
+func (rule RSSAutoDownloadingRule) MarshalJSON() ([]byte, error) {
+	type mask RSSAutoDownloadingRule
+	return marshalWithUnknownFields(mask(rule), rule.unknownFields)
+}
+
+// RSSTorrentParams holds the parameters applied to the torrents added by an auto-downloading rule.
+// Optional parameters are nil (and not sent) when the default behavior is used: the zero value uses the
+// qBittorrent defaults and global settings.
+type RSSTorrentParams struct {
+	Category                 string                `json:"category"`
+	Tags                     []string              `json:"tags"`
+	SavePath                 string                `json:"save_path"`
+	UseDownloadPath          *bool                 `json:"use_download_path,omitempty"`
+	DownloadPath             string                `json:"download_path"`
+	OperatingMode            *TorrentOperatingMode `json:"operating_mode,omitempty"`
+	AddToQueueTop            *bool                 `json:"add_to_queue_top,omitempty"`
+	Stopped                  *bool                 `json:"stopped,omitempty"`
+	StopCondition            *TorrentStopCondition `json:"stop_condition,omitempty"`
+	SkipChecking             *bool                 `json:"skip_checking,omitempty"`
+	ContentLayout            *TorrentContentLayout `json:"content_layout,omitempty"`
+	UseAutoTMM               *bool                 `json:"use_auto_tmm,omitempty"`
+	UploadLimit              *Speed                `json:"upload_limit,omitempty"`                // UnlimitedSpeedLimit if no limit is applied
+	DownloadLimit            *Speed                `json:"download_limit,omitempty"`              // UnlimitedSpeedLimit if no limit is applied
+	SeedingTimeLimit         *time.Duration        `json:"seeding_time_limit,omitempty"`          // -1 minute means no limit, -2 minutes means use global limit
+	InactiveSeedingTimeLimit *time.Duration        `json:"inactive_seeding_time_limit,omitempty"` // -1 minute means no limit, -2 minutes means use global limit
+	ShareLimitAction         ShareLimitAction      `json:"share_limit_action,omitempty"`
+	RatioLimit               *float64              `json:"ratio_limit,omitempty"` // -1 means no limit, -2 means use global limit
+	SSLCertificate           string                `json:"ssl_certificate"`
+	SSLPrivateKey            string                `json:"ssl_private_key"`
+	SSLDHParams              string                `json:"ssl_dh_params"`
+	// keys unknown to this model, by JSON key
+	unknownFields map[string]json.RawMessage
+}
+
+// NewRSSTorrentParams returns torrent parameters initialized with the qBittorrent defaults, explicitly set.
+func NewRSSTorrentParams() *RSSTorrentParams {
+	unlimited := UnlimitedSpeedLimit
+	globalSeedingTime := -2 * time.Minute
+	globalRatio := -2.0
+	return &RSSTorrentParams{
+		Tags:                     []string{},
+		OperatingMode:            TorrentOperatingModeAutoManaged.Ptr(),
+		UploadLimit:              &unlimited,
+		DownloadLimit:            &unlimited,
+		SeedingTimeLimit:         &globalSeedingTime,
+		InactiveSeedingTimeLimit: &globalSeedingTime,
+		ShareLimitAction:         ShareLimitActionDefault,
+		RatioLimit:               &globalRatio,
+	}
+}
+
+func (rtp *RSSTorrentParams) UnmarshalJSON(data []byte) (err error) {
+	type mask RSSTorrentParams
+	tmp := struct {
+		*mask
+		// Custom unmarshaling
+		UploadLimit              *int `json:"upload_limit"`                // Upload limit (bytes/s), -1 if unlimited
+		DownloadLimit            *int `json:"download_limit"`              // Download limit (bytes/s), -1 if unlimited
+		SeedingTimeLimit         *int `json:"seeding_time_limit"`          // Seeding time limit (minutes)
+		InactiveSeedingTimeLimit *int `json:"inactive_seeding_time_limit"` // Inactive seeding time limit (minutes)
+	}{
+		mask: (*mask)(rtp),
+	}
+	// Unmarshall to tmp struct
+	if err = json.Unmarshal(data, &tmp); err != nil {
+		return
+	}
+	// Adapt to golang types
+	rtp.UploadLimit = optionalSpeedFromBytes(tmp.UploadLimit)
+	rtp.DownloadLimit = optionalSpeedFromBytes(tmp.DownloadLimit)
+	rtp.SeedingTimeLimit = optionalMinutes(tmp.SeedingTimeLimit)
+	rtp.InactiveSeedingTimeLimit = optionalMinutes(tmp.InactiveSeedingTimeLimit)
+	rtp.unknownFields, err = extractUnknownFields(data, *rtp)
+	return
+}
+
+func (rtp RSSTorrentParams) MarshalJSON() ([]byte, error) {
+	type mask RSSTorrentParams
+	tmp := struct {
+		mask
+		// Custom marshaling
+		UploadLimit              *int `json:"upload_limit,omitempty"`                // Upload limit (bytes/s), -1 if unlimited
+		DownloadLimit            *int `json:"download_limit,omitempty"`              // Download limit (bytes/s), -1 if unlimited
+		SeedingTimeLimit         *int `json:"seeding_time_limit,omitempty"`          // Seeding time limit (minutes)
+		InactiveSeedingTimeLimit *int `json:"inactive_seeding_time_limit,omitempty"` // Inactive seeding time limit (minutes)
+	}{
+		mask:                     mask(rtp),
+		UploadLimit:              optionalSpeedToBytes(rtp.UploadLimit),
+		DownloadLimit:            optionalSpeedToBytes(rtp.DownloadLimit),
+		SeedingTimeLimit:         optionalToMinutes(rtp.SeedingTimeLimit),
+		InactiveSeedingTimeLimit: optionalToMinutes(rtp.InactiveSeedingTimeLimit),
+	}
+	if tmp.Tags == nil {
+		tmp.Tags = []string{}
+	}
+	return marshalWithUnknownFields(tmp, rtp.unknownFields)
+}
+
+func optionalSpeedFromBytes(bytes *int) *Speed {
+	if bytes == nil {
+		return nil
+	}
+	speed := GetSpeedFromBytes(*bytes)
+	return &speed
+}
+
+func optionalSpeedToBytes(speed *Speed) *int {
+	if speed == nil {
+		return nil
+	}
+	return Int(speed.ToBytes())
+}
+
+func optionalMinutes(minutes *int) *time.Duration {
+	if minutes == nil {
+		return nil
+	}
+	duration := time.Duration(*minutes) * time.Minute
+	return &duration
+}
+
+func optionalToMinutes(duration *time.Duration) *int {
+	if duration == nil {
+		return nil
+	}
+	return Int(int(*duration / time.Minute))
+}
+
+// extractUnknownFields returns the keys of the data JSON object not matching any JSON tag of the model struct.
+func extractUnknownFields(data []byte, model any) (unknown map[string]json.RawMessage, err error) {
+	if err = json.Unmarshal(data, &unknown); err != nil {
+		return
+	}
+	modelType := reflect.TypeOf(model)
+	for index := 0; index < modelType.NumField(); index++ {
+		key, _, _ := strings.Cut(modelType.Field(index).Tag.Get("json"), ",")
+		delete(unknown, key)
+	}
+	if len(unknown) == 0 {
+		unknown = nil
+	}
+	return
+}
+
+// marshalWithUnknownFields marshals value (which must marshal to a JSON object) and adds the unknown fields to it.
+func marshalWithUnknownFields(value any, unknown map[string]json.RawMessage) (data []byte, err error) {
+	if data, err = json.Marshal(value); err != nil || len(unknown) == 0 {
+		return
+	}
+	merged := make(map[string]json.RawMessage, len(unknown))
+	for key, raw := range unknown {
+		merged[key] = raw
+	}
+	// known fields take precedence
+	if err = json.Unmarshal(data, &merged); err != nil {
+		return
+	}
+	return json.Marshal(merged)
+}
+
+/*
+	RSS items tree
+*/
+
+// RSSPathSeparator separates the folder and feed names within RSS item paths (e.g. folder\feed).
+const RSSPathSeparator = "\\"
+
+// RSSItem is either a *RSSFolder or a *RSSFeed.
+type RSSItem interface {
+	UnreadCount() int
+}
+
+// RSSFolder represents a folder of the RSS tree. The root of the tree, as returned by GetAllRSSItems, is a folder too.
+type RSSFolder struct {
+	Folders map[string]*RSSFolder // Sub folders, by name
+	Feeds   map[string]*RSSFeed   // Feeds, by name
+}
+
+// RSSFeed represents a RSS feed. Fields other than UID and URL are only filled when the items were requested with data.
+type RSSFeed struct {
+	UID           string       `json:"uid"`
+	URL           string       `json:"url"`
+	Title         string       `json:"title,omitempty"`
+	LastBuildDate time.Time    `json:"lastBuildDate,omitempty"` // Zero if unknown or not parsable
+	IsLoading     bool         `json:"isLoading,omitempty"`
+	HasError      bool         `json:"hasError,omitempty"`
+	Articles      []RSSArticle `json:"articles,omitempty"`
+}
+
+// RSSArticle represents an article of a RSS feed.
+type RSSArticle struct {
+	ID          string    `json:"id"`
+	Date        time.Time `json:"date"` // Zero if unknown or not parsable
+	Title       string    `json:"title"`
+	Author      string    `json:"author,omitempty"`
+	Description string    `json:"description,omitempty"`
+	TorrentURL  *url.URL  `json:"torrentURL,omitempty"` // Torrent file or magnet link, nil if unknown or not parsable
+	Link        *url.URL  `json:"link,omitempty"`       // Article web page, nil if unknown or not parsable
+	IsRead      bool      `json:"isRead"`
+}
+
+func (f *RSSFolder) UnmarshalJSON(data []byte) (err error) {
+	var children map[string]json.RawMessage
+	if err = json.Unmarshal(data, &children); err != nil {
+		return
+	}
+	f.Folders = make(map[string]*RSSFolder)
+	f.Feeds = make(map[string]*RSSFeed)
+	for name, child := range children {
+		// legacy format: feeds are represented by their URL only
+		var feedURL string
+		if json.Unmarshal(child, &feedURL) == nil {
+			f.Feeds[name] = &RSSFeed{URL: feedURL}
+			continue
+		}
+		// feeds are objects with a string "url" key, folders are objects of items
+		var probe map[string]json.RawMessage
+		if err = json.Unmarshal(child, &probe); err != nil {
+			err = fmt.Errorf("parsing item %q failed: %w", name, err)
+			return
+		}
+		if json.Unmarshal(probe["url"], &feedURL) == nil {
+			feed := new(RSSFeed)
+			if err = json.Unmarshal(child, feed); err != nil {
+				err = fmt.Errorf("parsing feed %q failed: %w", name, err)
+				return
+			}
+			f.Feeds[name] = feed
+			continue
+		}
+		folder := new(RSSFolder)
+		if err = json.Unmarshal(child, folder); err != nil {
+			err = fmt.Errorf("parsing folder %q failed: %w", name, err)
+			return
+		}
+		f.Folders[name] = folder
+	}
+	return
+}
+
+func (f RSSFolder) MarshalJSON() ([]byte, error) {
+	children := make(map[string]any, len(f.Folders)+len(f.Feeds))
+	for name, folder := range f.Folders {
+		children[name] = folder
+	}
+	for name, feed := range f.Feeds {
+		children[name] = feed
+	}
+	return json.Marshal(children)
+}
+
+func (feed *RSSFeed) UnmarshalJSON(data []byte) (err error) {
+	type mask RSSFeed
+	tmp := struct {
+		*mask
+		// Custom unmarshaling
+		LastBuildDate string `json:"lastBuildDate"` // As sent by the feed itself
+	}{
+		mask: (*mask)(feed),
+	}
+	// Unmarshall to tmp struct
+	if err = json.Unmarshal(data, &tmp); err != nil {
+		return
+	}
+	// Adapt to golang types (feeds are not always compliant: ignore invalid dates)
+	feed.LastBuildDate, _ = parseRSSDate(tmp.LastBuildDate)
+	return
+}
+
+func (feed RSSFeed) MarshalJSON() ([]byte, error) {
+	type mask RSSFeed
+	tmp := struct {
+		mask
+		// Custom marshaling
+		LastBuildDate string `json:"lastBuildDate,omitempty"`
+	}{
+		mask: mask(feed),
+	}
+	if !feed.LastBuildDate.IsZero() {
+		tmp.LastBuildDate = feed.LastBuildDate.Format(time.RFC1123Z)
+	}
+	return json.Marshal(tmp)
+}
+
+func (ra *RSSArticle) UnmarshalJSON(data []byte) (err error) {
+	type mask RSSArticle
+	tmp := struct {
+		*mask
+		// Custom unmarshaling
+		Date       string `json:"date"`       // RFC 2822 date
+		TorrentURL string `json:"torrentURL"` // Torrent file or magnet link
+		Link       string `json:"link"`       // Article web page
+	}{
+		mask: (*mask)(ra),
+	}
+	// Unmarshall to tmp struct
+	if err = json.Unmarshal(data, &tmp); err != nil {
+		return
+	}
+	// Adapt to golang types (feeds are not always compliant: ignore invalid dates and URLs)
+	ra.Date, _ = parseRSSDate(tmp.Date)
+	ra.TorrentURL, _ = parseOptionalURL(tmp.TorrentURL)
+	ra.Link, _ = parseOptionalURL(tmp.Link)
+	return
+}
+
+func (ra RSSArticle) MarshalJSON() ([]byte, error) {
+	type mask RSSArticle
+	tmp := struct {
+		mask
+		// Custom marshaling
+		Date       string `json:"date"`
+		TorrentURL string `json:"torrentURL,omitempty"`
+		Link       string `json:"link,omitempty"`
+	}{
+		mask: mask(ra),
+	}
+	if !ra.Date.IsZero() {
+		tmp.Date = ra.Date.Format(time.RFC1123Z)
+	}
+	if ra.TorrentURL != nil {
+		tmp.TorrentURL = ra.TorrentURL.String()
+	}
+	if ra.Link != nil {
+		tmp.Link = ra.Link.String()
+	}
+	return json.Marshal(tmp)
+}
+
+// rssDateLayouts are the RFC 822/2822 variants found in RSS feeds, the day name and seconds being optional.
+var rssDateLayouts = []string{
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04 -0700",
+	time.RFC3339,
+}
+
+// parseRSSDate parses a RSS date, an empty string returning the zero time.
+func parseRSSDate(str string) (date time.Time, err error) {
+	if str = strings.TrimSpace(str); str == "" {
+		return
+	}
+	for _, layout := range rssDateLayouts {
+		if date, err = time.Parse(layout, str); err == nil {
+			return
+		}
+	}
+	err = fmt.Errorf("unknown date format: %q", str)
+	return
+}
+
+// parseOptionalURL parses an URL, an empty string returning a nil URL.
+func parseOptionalURL(str string) (*url.URL, error) {
+	if str == "" {
+		return nil, nil
+	}
+	return url.Parse(str)
+}
+
+// UnreadCount returns the number of unread articles within all the feeds of the folder and its sub folders.
+func (f *RSSFolder) UnreadCount() (count int) {
+	for _, folder := range f.Folders {
+		count += folder.UnreadCount()
+	}
+	for _, feed := range f.Feeds {
+		count += feed.UnreadCount()
+	}
+	return
+}
+
+// UnreadCount returns the number of unread articles of the feed.
+func (feed *RSSFeed) UnreadCount() (count int) {
+	for _, article := range feed.Articles {
+		if !article.IsRead {
+			count++
+		}
+	}
+	return
+}
+
+// Item returns the folder or feed at the given path (relative to f, names separated by RSSPathSeparator)
+// or nil if it does not exist. An empty path returns f itself.
+func (f *RSSFolder) Item(path string) RSSItem {
+	if path == "" {
+		return f
+	}
+	current := f
+	names := strings.Split(path, RSSPathSeparator)
+	for index, name := range names {
+		if index == len(names)-1 {
+			if folder, found := current.Folders[name]; found {
+				return folder
+			}
+			if feed, found := current.Feeds[name]; found {
+				return feed
+			}
+			return nil
+		}
+		if current = current.Folders[name]; current == nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Folder returns the folder at the given path or nil if it does not exist (or is a feed). See Item().
+func (f *RSSFolder) Folder(path string) *RSSFolder {
+	folder, _ := f.Item(path).(*RSSFolder)
+	return folder
+}
+
+// Feed returns the feed at the given path or nil if it does not exist (or is a folder). See Item().
+func (f *RSSFolder) Feed(path string) *RSSFeed {
+	feed, _ := f.Item(path).(*RSSFeed)
+	return feed
+}
+
+// SkipRSSFolder can be returned by a RSSWalkFunc called on a folder to skip its content.
+var SkipRSSFolder = errors.New("skip this RSS folder")
+
+// RSSWalkFunc is called by RSSFolder.Walk() for each item of the tree, item being either a *RSSFolder or a *RSSFeed.
+type RSSWalkFunc func(path string, item RSSItem) error
+
+// Walk calls fn for every folder and feed under f (f itself excluded), parents before their children and
+// siblings sorted by name (folders first). Walking stops at the first error returned by fn, which is returned
+// by Walk, except for SkipRSSFolder which only skips the content of the folder fn was called on.
+func (f *RSSFolder) Walk(fn RSSWalkFunc) error {
+	return f.walk("", fn)
+}
+
+func (f *RSSFolder) walk(prefix string, fn RSSWalkFunc) (err error) {
+	for _, name := range sortedKeys(f.Folders) {
+		path := prefix + name
+		if err = fn(path, f.Folders[name]); err != nil {
+			if err == SkipRSSFolder {
+				err = nil
+				continue
+			}
+			return
+		}
+		if err = f.Folders[name].walk(path+RSSPathSeparator, fn); err != nil {
+			return
+		}
+	}
+	for _, name := range sortedKeys(f.Feeds) {
+		if err = fn(prefix+name, f.Feeds[name]); err != nil {
+			if err == SkipRSSFolder {
+				err = nil
+				continue
+			}
+			return
+		}
+	}
+	return
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
 
 // AddRSSFolder adds a new RSS folder at the given path.
 // https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#add-folder
@@ -99,10 +586,10 @@ func (c *Client) MoveRSSItem(ctx context.Context, itemPath, destPath string) (er
 	return
 }
 
-// GetAllRSSItems returns all RSS items (feeds and folders).
-// If withData is true, current feed articles are included.
+// GetAllRSSItems returns all RSS items (feeds and folders) as a tree whose root is returned.
+// If withData is true, feeds details and current articles are included.
 // https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#get-all-items
-func (c *Client) GetAllRSSItems(ctx context.Context, withData *bool) (items RSSItems, err error) {
+func (c *Client) GetAllRSSItems(ctx context.Context, withData *bool) (root RSSFolder, err error) {
 	params := make(map[string]string)
 	if withData != nil {
 		params["withData"] = strconv.FormatBool(*withData)
@@ -112,12 +599,91 @@ func (c *Client) GetAllRSSItems(ctx context.Context, withData *bool) (items RSSI
 		err = fmt.Errorf("building request failed: %w", err)
 		return
 	}
-	if err = c.requestExecute(req, &items, true); err != nil {
+	if err = c.requestExecute(req, &root, true); err != nil {
 		err = fmt.Errorf("executing request failed: %w", err)
 	}
 	return
 }
 
+// SetRSSFeedURL changes the URL of an existing feed, keeping its articles and read state.
+// https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#set-feed-url
+func (c *Client) SetRSSFeedURL(ctx context.Context, path, url string) (err error) {
+	req, err := c.requestBuild(ctx, "POST", rssAPIName, "setFeedURL", map[string]string{
+		"path": path,
+		"url":  url,
+	}, nil)
+	if err != nil {
+		err = fmt.Errorf("building request failed: %w", err)
+		return
+	}
+	if err = c.requestExecute(req, nil, true); err != nil {
+		err = fmt.Errorf("executing request failed: %w", err)
+	}
+	return
+}
+
+// SetRSSFeedRefreshInterval sets the refresh interval of a feed (with a seconds granularity).
+// A zero interval makes the feed use the global refresh interval.
+// https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#set-feed-refresh-interval
+func (c *Client) SetRSSFeedRefreshInterval(ctx context.Context, path string, interval time.Duration) (err error) {
+	req, err := c.requestBuild(ctx, "POST", rssAPIName, "setFeedRefreshInterval", map[string]string{
+		"path":            path,
+		"refreshInterval": strconv.FormatInt(int64(interval/time.Second), 10),
+	}, nil)
+	if err != nil {
+		err = fmt.Errorf("building request failed: %w", err)
+		return
+	}
+	if err = c.requestExecute(req, nil, true); err != nil {
+		err = fmt.Errorf("executing request failed: %w", err)
+	}
+	return
+}
+
+// RSSFeedURLChange describes the URL change of a feed.
+type RSSFeedURLChange struct {
+	Path   string
+	OldURL string
+	NewURL string
+}
+
+// RotateFeedPasskey rewrites the URL of every feed of the tree matching pattern, replacing the matches with
+// replacement (see regexp.Regexp.ReplaceAllString() for the replacement syntax). Feeds are updated in place
+// with SetRSSFeedURL(): articles, read state and auto-downloading rules bindings are kept by the server.
+// If dryRun is true, changes are only computed. Changes applied before an error are returned alongside it.
+func (c *Client) RotateFeedPasskey(ctx context.Context, pattern *regexp.Regexp, replacement string, dryRun bool) (changes []RSSFeedURLChange, err error) {
+	root, err := c.GetAllRSSItems(ctx, nil)
+	if err != nil {
+		err = fmt.Errorf("getting RSS items failed: %w", err)
+		return
+	}
+	var planned []RSSFeedURLChange
+	_ = root.Walk(func(path string, item RSSItem) error {
+		if feed, isFeed := item.(*RSSFeed); isFeed && pattern.MatchString(feed.URL) {
+			if newURL := pattern.ReplaceAllString(feed.URL, replacement); newURL != feed.URL {
+				planned = append(planned, RSSFeedURLChange{
+					Path:   path,
+					OldURL: feed.URL,
+					NewURL: newURL,
+				})
+			}
+		}
+		return nil
+	})
+	if dryRun {
+		changes = planned
+		return
+	}
+	for _, change := range planned {
+		if err = c.SetRSSFeedURL(ctx, change.Path, change.NewURL); err != nil {
+			err = fmt.Errorf("setting URL of feed %q failed: %w", change.Path, err)
+			return
+		}
+		changes = append(changes, change)
+	}
+	return
+}
+
 // MarkRSSItemAsRead marks an RSS feed or a specific article as read.
 // If articleID is provided, only that article is marked as read.
 // https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#mark-as-read
@@ -2,9 +2,196 @@ package qbtapi
 
 import (
 	"context"
+	"encoding/json"
+	"reflect"
+	"regexp"
 	"testing"
+	"time"
 )
 
+func TestRSSItemsTree(t *testing.T) {
+	payload := `{
+		"linux": {
+			"distros": {
+				"ubuntu": {
+					"uid": "{3b5a7c1e-0000-4000-8000-000000000001}",
+					"url": "https://example.com/ubuntu.rss",
+					"title": "Ubuntu releases",
+					"lastBuildDate": "Tue, 08 Oct 2024 12:00:00 +0000",
+					"isLoading": false,
+					"hasError": false,
+					"articles": [
+						{"id": "1", "date": "08 Oct 2024 12:00:00 +0000", "title": "24.10", "torrentURL": "magnet:?xt=urn:btih:aaaa", "isRead": false},
+						{"id": "2", "date": "Thu, 1 Aug 2024 09:30:00 +0200", "title": "24.04.1", "link": "https://example.com/24.04.1", "isRead": true}
+					]
+				}
+			},
+			"debian": "https://example.com/debian.rss"
+		},
+		"news": {"uid": "{3b5a7c1e-0000-4000-8000-000000000002}", "url": "https://example.com/news.rss"}
+	}`
+	var root RSSFolder
+	if err := json.Unmarshal([]byte(payload), &root); err != nil {
+		t.Fatalf("unmarshaling RSS items: %v", err)
+	}
+
+	// ── path lookup ─────────────────────────────────────────
+	feed := root.Feed(`linux\distros\ubuntu`)
+	if feed == nil {
+		t.Fatal(`feed linux\distros\ubuntu not found`)
+	}
+	if len(feed.Articles) != 2 || feed.Articles[0].TorrentURL == nil || feed.Articles[0].TorrentURL.Scheme != "magnet" {
+		t.Fatalf("unexpected feed articles: %+v", feed.Articles)
+	}
+	if expected := time.Date(2024, time.August, 1, 7, 30, 0, 0, time.UTC); !feed.Articles[1].Date.Equal(expected) {
+		t.Fatalf("article date not parsed: expected %v, got %v", expected, feed.Articles[1].Date)
+	}
+	var article RSSArticle
+	if err := json.Unmarshal([]byte(`{"id": "3", "date": "not a date", "title": "broken", "torrentURL": "http://[::1", "link": "%zz"}`), &article); err != nil {
+		t.Fatalf("unparsable article values should be ignored: %v", err)
+	}
+	if !article.Date.IsZero() || article.TorrentURL != nil || article.Link != nil || article.Title != "broken" {
+		t.Fatalf("unexpected lenient article: %+v", article)
+	}
+	if root.Feed(`linux\debian`) == nil || root.Folder("news") != nil || root.Item(`linux\missing\feed`) != nil {
+		t.Fatal("path lookup mixed up feeds and folders")
+	}
+
+	// ── unread counts and walker ────────────────────────────
+	if count := root.UnreadCount(); count != 1 {
+		t.Fatalf("expected 1 unread article, got %d", count)
+	}
+	var paths []string
+	if err := root.Walk(func(path string, item RSSItem) error {
+		paths = append(paths, path)
+		if path == `linux\distros` {
+			return SkipRSSFolder
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	expectedPaths := []string{"linux", `linux\distros`, `linux\debian`, "news"}
+	if len(paths) != len(expectedPaths) {
+		t.Fatalf("unexpected walk: %v", paths)
+	}
+	for index := range paths {
+		if paths[index] != expectedPaths[index] {
+			t.Fatalf("unexpected walk: %v", paths)
+		}
+	}
+
+	// ── JSON round trip ─────────────────────────────────────
+	data, err := json.Marshal(root)
+	if err != nil {
+		t.Fatalf("marshaling RSS items: %v", err)
+	}
+	var roundTrip RSSFolder
+	if err = json.Unmarshal(data, &roundTrip); err != nil {
+		t.Fatalf("unmarshaling marshaled RSS items: %v", err)
+	}
+	rtFeed := roundTrip.Feed(`linux\distros\ubuntu`)
+	if rtFeed == nil || rtFeed.UID != feed.UID || !rtFeed.LastBuildDate.Equal(feed.LastBuildDate) ||
+		len(rtFeed.Articles) != 2 || !rtFeed.Articles[1].Date.Equal(feed.Articles[1].Date) ||
+		rtFeed.Articles[0].TorrentURL.String() != feed.Articles[0].TorrentURL.String() {
+		t.Fatalf("round trip mismatch: %+v", rtFeed)
+	}
+	if roundTrip.Feed(`linux\debian`) == nil || roundTrip.UnreadCount() != 1 {
+		t.Fatalf("round trip mismatch: %s", data)
+	}
+}
+
+func TestRSSAutoDownloadingRuleRoundTrip(t *testing.T) {
+	// as edited with a qBittorrent 5 WebUI, plus a key unknown to the model
+	payload := `{
+		"enabled": true,
+		"mustContain": "show 1080p",
+		"mustNotContain": "",
+		"useRegex": false,
+		"episodeFilter": "1x2-;",
+		"smartFilter": true,
+		"previouslyMatchedEpisodes": ["1x2"],
+		"affectedFeeds": ["https://example.com/feed.rss"],
+		"ignoreDays": 0,
+		"lastMatch": "08 Oct 2024 12:00:00 +0000",
+		"priority": 1,
+		"addPaused": null,
+		"torrentContentLayout": null,
+		"assignedCategory": "tv",
+		"savePath": "",
+		"futureKey": {"nested": [1, 2]},
+		"torrentParams": {
+			"category": "tv",
+			"tags": ["rss", "hd"],
+			"save_path": "/data/tv",
+			"download_path": "",
+			"use_download_path": false,
+			"operating_mode": "AutoManaged",
+			"stopped": true,
+			"stop_condition": "MetadataReceived",
+			"skip_checking": false,
+			"content_layout": "Subfolder",
+			"upload_limit": -1,
+			"download_limit": 1048576,
+			"seeding_time_limit": 1440,
+			"inactive_seeding_time_limit": -2,
+			"share_limit_action": "Stop",
+			"ratio_limit": 2.5,
+			"ssl_certificate": "",
+			"ssl_private_key": "",
+			"ssl_dh_params": "",
+			"futureParam": true
+		}
+	}`
+	var rule RSSAutoDownloadingRule
+	if err := json.Unmarshal([]byte(payload), &rule); err != nil {
+		t.Fatalf("unmarshaling rule: %v", err)
+	}
+	params := rule.TorrentParams
+	if params == nil || params.ContentLayout == nil || *params.ContentLayout != TorrentContentLayoutSubfolder ||
+		params.StopCondition == nil || *params.StopCondition != TorrentStopConditionMetadataReceived ||
+		params.UploadLimit == nil || !params.UploadLimit.Unlimited() ||
+		params.DownloadLimit == nil || params.DownloadLimit.ToBytes() != 1048576 ||
+		params.SeedingTimeLimit == nil || *params.SeedingTimeLimit != 24*time.Hour ||
+		params.RatioLimit == nil || *params.RatioLimit != 2.5 || params.ShareLimitAction != ShareLimitActionStop {
+		t.Fatalf("unexpected torrent params: %+v", params)
+	}
+	if rule.AddPaused != nil || rule.Priority != 1 {
+		t.Fatalf("unexpected rule: %+v", rule)
+	}
+
+	// ── JSON round trip ─────────────────────────────────────
+	data, err := json.Marshal(rule)
+	if err != nil {
+		t.Fatalf("marshaling rule: %v", err)
+	}
+	var original, roundTrip map[string]any
+	if err = json.Unmarshal([]byte(payload), &original); err != nil {
+		t.Fatalf("unmarshaling payload: %v", err)
+	}
+	if err = json.Unmarshal(data, &roundTrip); err != nil {
+		t.Fatalf("unmarshaling marshaled rule: %v", err)
+	}
+	if !reflect.DeepEqual(original, roundTrip) {
+		t.Fatalf("round trip mismatch:\n%s\n%s", payload, data)
+	}
+
+	// ── zero params use the defaults ────────────────────────
+	if data, err = json.Marshal(RSSTorrentParams{}); err != nil {
+		t.Fatalf("marshaling zero params: %v", err)
+	}
+	var zero map[string]any
+	if err = json.Unmarshal(data, &zero); err != nil {
+		t.Fatalf("unmarshaling zero params: %v", err)
+	}
+	for _, key := range []string{"operating_mode", "skip_checking", "upload_limit", "download_limit",
+		"seeding_time_limit", "inactive_seeding_time_limit", "share_limit_action", "ratio_limit"} {
+		if value, found := zero[key]; found {
+			t.Errorf("zero params: %s sent as %v instead of using the default", key, value)
+		}
+	}
+}
+
 func TestRSSDomain(t *testing.T) {
 	c := newTestClient(t)
 	ctx := context.Background()
@@ -26,10 +213,10 @@ func TestRSSDomain(t *testing.T) {
 	if err != nil {
 		t.Fatalf("GetAllRSSItems: %v", err)
 	}
-	if _, ok := items["test-folder"]; !ok {
+	if items.Folder("test-folder") == nil {
 		t.Fatalf("folder 'test-folder' not found in RSS items")
 	}
-	t.Logf("RSS items count: %d", len(items))
+	t.Logf("RSS items count: %d folders, %d feeds", len(items.Folders), len(items.Feeds))
 
 	// ── move folder ─────────────────────────────────────────
 	if err := c.MoveRSSItem(ctx, "test-folder", "test-folder-moved"); err != nil {
@@ -39,10 +226,10 @@ func TestRSSDomain(t *testing.T) {
 	if err != nil {
 		t.Fatalf("GetAllRSSItems (after move): %v", err)
 	}
-	if _, ok := items["test-folder"]; ok {
+	if items.Item("test-folder") != nil {
 		t.Fatalf("old folder 'test-folder' still exists after move")
 	}
-	if _, ok := items["test-folder-moved"]; !ok {
+	if items.Folder("test-folder-moved") == nil {
 		t.Fatalf("new folder 'test-folder-moved' not found after move")
 	}
 
@@ -54,7 +241,7 @@ func TestRSSDomain(t *testing.T) {
 	if err != nil {
 		t.Fatalf("GetAllRSSItems (after remove): %v", err)
 	}
-	if _, ok := items["test-folder-moved"]; ok {
+	if items.Item("test-folder-moved") != nil {
 		t.Fatalf("folder 'test-folder-moved' still exists after removal")
 	}
 
@@ -67,9 +254,33 @@ func TestRSSDomain(t *testing.T) {
 		if err := c.RefreshRSSItem(ctx, feedPath); err != nil {
 			t.Logf("RefreshRSSItem: %v", err)
 		}
+		if items, err = c.GetAllRSSItems(ctx, Bool(true)); err != nil {
+			t.Fatalf("GetAllRSSItems (with data): %v", err)
+		}
+		if feed := items.Feed(feedPath); feed == nil || feed.URL != feedURL {
+			t.Fatalf("feed %q not found in RSS items: %+v", feedPath, items.Feeds)
+		} else {
+			t.Logf("feed %q: %d articles, %d unread", feed.Title, len(feed.Articles), feed.UnreadCount())
+		}
 		if err := c.MarkRSSItemAsRead(ctx, feedPath, nil); err != nil {
 			t.Logf("MarkRSSItemAsRead: %v", err)
 		}
+		changes, err := c.RotateFeedPasskey(ctx, regexp.MustCompile(`releases\.rss$`), "releases.rss?passkey=rotated", false)
+		if err != nil {
+			t.Fatalf("RotateFeedPasskey: %v", err)
+		}
+		if len(changes) != 1 || changes[0].Path != feedPath {
+			t.Fatalf("unexpected feed URL changes: %+v", changes)
+		}
+		if items, err = c.GetAllRSSItems(ctx, nil); err != nil {
+			t.Fatalf("GetAllRSSItems (after passkey rotation): %v", err)
+		}
+		if feed := items.Feed(feedPath); feed == nil || feed.URL != changes[0].NewURL {
+			t.Fatalf("feed URL not rotated: %+v", feed)
+		}
+		if err := c.SetRSSFeedRefreshInterval(ctx, feedPath, time.Hour); err != nil {
+			t.Logf("SetRSSFeedRefreshInterval: %v", err)
+		}
 		if err := c.RemoveRSSItem(ctx, feedPath); err != nil {
 			t.Fatalf("RemoveRSSItem (feed): %v", err)
 		}
@@ -80,7 +291,7 @@ func TestRSSDomain(t *testing.T) {
 	rule := RSSAutoDownloadingRule{
 		Enabled:          true,
 		MustContain:      "test",
-		AddPaused:        true,
+		AddPaused:        Bool(true),
 		AssignedCategory: "",
 		SavePath:         "",
 	}
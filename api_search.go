@@ -11,11 +11,19 @@ const searchAPIName = "search"
 
 // SearchJob represents the status of a search job.
 type SearchJob struct {
-	ID     int    `json:"id"`
-	Status string `json:"status"`
-	Total  int    `json:"total"`
+	ID     int             `json:"id"`
+	Status SearchJobStatus `json:"status"`
+	Total  int             `json:"total"`
 }
 
+// SearchJobStatus is the status of a search job.
+type SearchJobStatus string
+
+const (
+	SearchJobStatusRunning SearchJobStatus = "Running" // Plugins are still searching
+	SearchJobStatusStopped SearchJobStatus = "Stopped" // Search is over: finished, stopped or failed
+)
+
 // SearchResult represents a single search result.
 type SearchResult struct {
 	DescrLink  string `json:"descrLink"`
@@ -25,6 +33,7 @@ type SearchResult struct {
 	NbLeechers int    `json:"nbLeechers"`
 	NbSeeders  int    `json:"nbSeeders"`
 	SiteURL    string `json:"siteUrl"`
+	EngineName string `json:"engineName"` // Name of the plugin which found the result
 }
 
 // SearchPluginCategory represents a category supported by a search plugin.
@@ -45,9 +54,9 @@ type SearchPlugin struct {
 
 // SearchResults contains the results of a search query.
 type SearchResults struct {
-	Results []SearchResult `json:"results"`
-	Status  string         `json:"status"`
-	Total   int            `json:"total"`
+	Results []SearchResult  `json:"results"`
+	Status  SearchJobStatus `json:"status"`
+	Total   int             `json:"total"`
 }
 
 // StartSearch starts a new search and returns the job ID.
@@ -147,6 +156,24 @@ func (c *Client) DeleteSearch(ctx context.Context, id int) (err error) {
 	return
 }
 
+// DownloadSearchTorrent downloads a search result torrent through the plugin which found it
+// (allowing plugins requiring a login to fetch the torrent file) and adds it with the default options.
+// https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#download-torrent
+func (c *Client) DownloadSearchTorrent(ctx context.Context, torrentURL, pluginName string) (err error) {
+	req, err := c.requestBuild(ctx, "POST", searchAPIName, "downloadTorrent", map[string]string{
+		"torrentUrl": torrentURL,
+		"pluginName": pluginName,
+	}, nil)
+	if err != nil {
+		err = fmt.Errorf("building request failed: %w", err)
+		return
+	}
+	if err = c.requestExecute(req, nil, true); err != nil {
+		err = fmt.Errorf("executing request failed: %w", err)
+	}
+	return
+}
+
 // GetSearchPlugins returns all installed search plugins.
 // https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#get-search-plugins
 func (c *Client) GetSearchPlugins(ctx context.Context) (plugins []SearchPlugin, err error) {
@@ -2,8 +2,15 @@ package qbtapi
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/netip"
+	"reflect"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hekmon/cunits/v3"
 )
 
 const syncAPIName = "sync"
@@ -22,12 +29,223 @@ type SyncMainData struct {
 }
 
 // SyncServerState represents the global server state returned in sync updates.
-// It contains the same fields as GlobalTransferInfo plus additional partial-update fields.
+// It contains the same fields as GlobalTransferInfo plus additional server statistics.
+// As incremental updates only contain the fields which changed, use Has() to know if a field
+// was actually sent by the server and Update() to apply an incremental update on a previous state.
 type SyncServerState struct {
 	GlobalTransferInfo
-	Queueing          bool `json:"queueing"`             // True if torrent queueing is enabled
-	UseAltSpeedLimits bool `json:"use_alt_speed_limits"` // True if alternative speed limits are enabled
-	RefreshInterval   int  `json:"refresh_interval"`     // Transfer list refresh interval (milliseconds)
+	AllTimeDownload       cunits.Bits   `json:"alltime_dl"`               // Data downloaded since the first start of the application
+	AllTimeUpload         cunits.Bits   `json:"alltime_ul"`               // Data uploaded since the first start of the application
+	AverageTimeQueue      time.Duration `json:"average_time_queue"`       // Average time a disk job spends in the queue
+	FreeSpaceOnDisk       cunits.Bits   `json:"free_space_on_disk"`       // Free space on the default save path disk
+	GlobalRatio           float64       `json:"global_ratio"`             // Global share ratio (all time)
+	LastExternalAddressV4 netip.Addr    `json:"last_external_address_v4"` // Last known external IPv4 address (invalid if unknown)
+	LastExternalAddressV6 netip.Addr    `json:"last_external_address_v6"` // Last known external IPv6 address (invalid if unknown)
+	QueuedIOJobs          int           `json:"queued_io_jobs"`           // Number of disk jobs waiting in the queue
+	Queueing              bool          `json:"queueing"`                 // True if torrent queueing is enabled
+	ReadCacheHits         float64       `json:"read_cache_hits"`          // Read cache hits (percentage)
+	ReadCacheOverload     float64       `json:"read_cache_overload"`      // Read cache overload (percentage)
+	RefreshInterval       time.Duration `json:"refresh_interval"`         // Transfer list refresh interval
+	TotalBuffersSize      cunits.Bits   `json:"total_buffers_size"`       // Total size of the disk buffers
+	TotalPeerConnections  int           `json:"total_peer_connections"`   // Number of peer connections across all torrents
+	TotalQueuedSize       cunits.Bits   `json:"total_queued_size"`        // Total size of the queued disk jobs
+	TotalWastedSession    cunits.Bits   `json:"total_wasted_session"`     // Data wasted this session
+	UseAltSpeedLimits     bool          `json:"use_alt_speed_limits"`     // True if alternative speed limits are enabled
+	UseSubcategories      bool          `json:"use_subcategories"`        // True if subcategories are enabled
+	WriteCacheOverload    float64       `json:"write_cache_overload"`     // Write cache overload (percentage)
+	// fields present in the server payload, by JSON key
+	fields map[string]struct{}
+}
+
+// syncServerStateWire holds the SyncServerState fields not belonging to GlobalTransferInfo, as sent on the wire.
+// GlobalTransferInfo has its own (un)marshaling methods which would be promoted through a mask of
+// SyncServerState: it is handled separately.
+type syncServerStateWire struct {
+	AllTimeDownload       int64           `json:"alltime_dl"`               // Data downloaded since the first start of the application (bytes)
+	AllTimeUpload         int64           `json:"alltime_ul"`               // Data uploaded since the first start of the application (bytes)
+	AverageTimeQueue      int64           `json:"average_time_queue"`       // Average time a disk job spends in the queue (milliseconds)
+	FreeSpaceOnDisk       int64           `json:"free_space_on_disk"`       // Free space on the default save path disk (bytes)
+	GlobalRatio           json.RawMessage `json:"global_ratio"`             // Global share ratio, sent as a string
+	LastExternalAddressV4 string          `json:"last_external_address_v4"` // Last known external IPv4 address
+	LastExternalAddressV6 string          `json:"last_external_address_v6"` // Last known external IPv6 address
+	QueuedIOJobs          int             `json:"queued_io_jobs"`           // Number of disk jobs waiting in the queue
+	Queueing              bool            `json:"queueing"`                 // True if torrent queueing is enabled
+	ReadCacheHits         json.RawMessage `json:"read_cache_hits"`          // Read cache hits, sent as a string
+	ReadCacheOverload     json.RawMessage `json:"read_cache_overload"`      // Read cache overload, sent as a string
+	RefreshInterval       int64           `json:"refresh_interval"`         // Transfer list refresh interval (milliseconds)
+	TotalBuffersSize      int64           `json:"total_buffers_size"`       // Total size of the disk buffers (bytes)
+	TotalPeerConnections  int             `json:"total_peer_connections"`   // Number of peer connections across all torrents
+	TotalQueuedSize       int64           `json:"total_queued_size"`        // Total size of the queued disk jobs (bytes)
+	TotalWastedSession    int64           `json:"total_wasted_session"`     // Data wasted this session (bytes)
+	UseAltSpeedLimits     bool            `json:"use_alt_speed_limits"`     // True if alternative speed limits are enabled
+	UseSubcategories      bool            `json:"use_subcategories"`        // True if subcategories are enabled
+	WriteCacheOverload    json.RawMessage `json:"write_cache_overload"`     // Write cache overload, sent as a string
+}
+
+func (sss *SyncServerState) UnmarshalJSON(data []byte) (err error) {
+	// Unmarshall the transfer info part and the rest to the wire struct
+	if err = json.Unmarshal(data, &sss.GlobalTransferInfo); err != nil {
+		return
+	}
+	var tmp syncServerStateWire
+	if err = json.Unmarshal(data, &tmp); err != nil {
+		return
+	}
+	// Register which fields were sent
+	var present map[string]json.RawMessage
+	if err = json.Unmarshal(data, &present); err != nil {
+		return
+	}
+	sss.fields = make(map[string]struct{}, len(present))
+	for key := range present {
+		sss.fields[key] = struct{}{}
+	}
+	// Adapt to golang types
+	sss.AllTimeDownload = cunits.ImportInBytes(float64(tmp.AllTimeDownload))
+	sss.AllTimeUpload = cunits.ImportInBytes(float64(tmp.AllTimeUpload))
+	sss.AverageTimeQueue = time.Duration(tmp.AverageTimeQueue) * time.Millisecond
+	sss.FreeSpaceOnDisk = cunits.ImportInBytes(float64(tmp.FreeSpaceOnDisk))
+	if sss.GlobalRatio, err = parseStringFloat(tmp.GlobalRatio); err != nil {
+		err = fmt.Errorf("parsing global ratio failed: %w", err)
+		return
+	}
+	if sss.LastExternalAddressV4, err = parseOptionalAddr(tmp.LastExternalAddressV4); err != nil {
+		err = fmt.Errorf("parsing last external IPv4 address failed: %w", err)
+		return
+	}
+	if sss.LastExternalAddressV6, err = parseOptionalAddr(tmp.LastExternalAddressV6); err != nil {
+		err = fmt.Errorf("parsing last external IPv6 address failed: %w", err)
+		return
+	}
+	sss.QueuedIOJobs = tmp.QueuedIOJobs
+	sss.Queueing = tmp.Queueing
+	if sss.ReadCacheHits, err = parseStringFloat(tmp.ReadCacheHits); err != nil {
+		err = fmt.Errorf("parsing read cache hits failed: %w", err)
+		return
+	}
+	if sss.ReadCacheOverload, err = parseStringFloat(tmp.ReadCacheOverload); err != nil {
+		err = fmt.Errorf("parsing read cache overload failed: %w", err)
+		return
+	}
+	sss.RefreshInterval = time.Duration(tmp.RefreshInterval) * time.Millisecond
+	sss.TotalBuffersSize = cunits.ImportInBytes(float64(tmp.TotalBuffersSize))
+	sss.TotalPeerConnections = tmp.TotalPeerConnections
+	sss.TotalQueuedSize = cunits.ImportInBytes(float64(tmp.TotalQueuedSize))
+	sss.TotalWastedSession = cunits.ImportInBytes(float64(tmp.TotalWastedSession))
+	sss.UseAltSpeedLimits = tmp.UseAltSpeedLimits
+	sss.UseSubcategories = tmp.UseSubcategories
+	if sss.WriteCacheOverload, err = parseStringFloat(tmp.WriteCacheOverload); err != nil {
+		err = fmt.Errorf("parsing write cache overload failed: %w", err)
+		return
+	}
+	return
+}
+
+func (sss SyncServerState) MarshalJSON() ([]byte, error) {
+	tmp := syncServerStateWire{
+		AllTimeDownload:      int64(sss.AllTimeDownload.Bytes()),
+		AllTimeUpload:        int64(sss.AllTimeUpload.Bytes()),
+		AverageTimeQueue:     sss.AverageTimeQueue.Milliseconds(),
+		FreeSpaceOnDisk:      int64(sss.FreeSpaceOnDisk.Bytes()),
+		GlobalRatio:          marshalStringFloat(sss.GlobalRatio, 2),
+		QueuedIOJobs:         sss.QueuedIOJobs,
+		Queueing:             sss.Queueing,
+		ReadCacheHits:        marshalStringFloat(sss.ReadCacheHits, -1),
+		ReadCacheOverload:    marshalStringFloat(sss.ReadCacheOverload, -1),
+		RefreshInterval:      sss.RefreshInterval.Milliseconds(),
+		TotalBuffersSize:     int64(sss.TotalBuffersSize.Bytes()),
+		TotalPeerConnections: sss.TotalPeerConnections,
+		TotalQueuedSize:      int64(sss.TotalQueuedSize.Bytes()),
+		TotalWastedSession:   int64(sss.TotalWastedSession.Bytes()),
+		UseAltSpeedLimits:    sss.UseAltSpeedLimits,
+		UseSubcategories:     sss.UseSubcategories,
+		WriteCacheOverload:   marshalStringFloat(sss.WriteCacheOverload, -1),
+	}
+	if sss.LastExternalAddressV4.IsValid() {
+		tmp.LastExternalAddressV4 = sss.LastExternalAddressV4.String()
+	}
+	if sss.LastExternalAddressV6.IsValid() {
+		tmp.LastExternalAddressV6 = sss.LastExternalAddressV6.String()
+	}
+	// Merge both parts within a single JSON object
+	merged := make(map[string]json.RawMessage)
+	for _, part := range []any{sss.GlobalTransferInfo, tmp} {
+		data, err := json.Marshal(part)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(data, &merged); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(merged)
+}
+
+// Has returns true if the field identified by its JSON key (e.g. "free_space_on_disk") was sent by the server.
+// Within incremental updates, a field not sent means it did not change since the previous update.
+func (sss SyncServerState) Has(key string) bool {
+	_, found := sss.fields[key]
+	return found
+}
+
+// Update applies an incremental server state on top of sss: only the fields sent within delta are copied.
+func (sss *SyncServerState) Update(delta SyncServerState) {
+	updateSentFields(reflect.ValueOf(sss).Elem(), reflect.ValueOf(delta), delta.fields)
+	if sss.fields == nil {
+		sss.fields = make(map[string]struct{}, len(delta.fields))
+	}
+	for key := range delta.fields {
+		sss.fields[key] = struct{}{}
+	}
+}
+
+func updateSentFields(target, delta reflect.Value, sent map[string]struct{}) {
+	for index := 0; index < target.NumField(); index++ {
+		field := target.Type().Field(index)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			updateSentFields(target.Field(index), delta.Field(index), sent)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if _, found := sent[key]; found {
+			target.Field(index).Set(delta.Field(index))
+		}
+	}
+}
+
+// parseStringFloat parses a float qBittorrent sends as a JSON string (a JSON number is also accepted).
+// An absent value is parsed as 0.
+func parseStringFloat(raw json.RawMessage) (value float64, err error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return
+	}
+	var str string
+	if err = json.Unmarshal(raw, &str); err != nil {
+		// not a string, try a regular number
+		err = json.Unmarshal(raw, &value)
+		return
+	}
+	if str == "" {
+		return
+	}
+	return strconv.ParseFloat(str, 64)
+}
+
+// marshalStringFloat formats a float the way qBittorrent sends it: as a JSON string.
+func marshalStringFloat(value float64, precision int) json.RawMessage {
+	data, _ := json.Marshal(strconv.FormatFloat(value, 'f', precision, 64))
+	return data
+}
+
+// parseOptionalAddr parses an IP address, an empty string returning the zero (invalid) address.
+func parseOptionalAddr(str string) (addr netip.Addr, err error) {
+	if str == "" {
+		return
+	}
+	return netip.ParseAddr(str)
 }
 
 // TorrentPeerData represents information about a single peer in a torrent.
@@ -2,7 +2,9 @@ package qbtapi
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 )
 
 func TestSyncDomain(t *testing.T) {
@@ -19,6 +21,11 @@ func TestSyncDomain(t *testing.T) {
 	if mainData.ServerState.ConnectionStatus == "" {
 		t.Fatal("GetMainData returned empty connection status in server_state")
 	}
+	if !mainData.ServerState.Has("free_space_on_disk") {
+		t.Fatal("GetMainData full update did not report free_space_on_disk in server_state")
+	}
+	t.Logf("server state: free_space=%s alltime_dl=%s global_ratio=%.2f",
+		mainData.ServerState.FreeSpaceOnDisk, mainData.ServerState.AllTimeDownload, mainData.ServerState.GlobalRatio)
 
 	// ── incremental main data ───────────────────────────────
 	incremental, err := c.GetMainData(ctx, mainData.RID)
@@ -44,3 +51,58 @@ func TestSyncDomain(t *testing.T) {
 	t.Logf("torrent peers data: rid=%d full_update=%v peers=%d",
 		peersData.RID, peersData.FullUpdate, len(peersData.Peers))
 }
+
+func TestSyncServerStateUpdate(t *testing.T) {
+	var state SyncServerState
+	full := `{"alltime_dl":1024,"connection_status":"connected","dl_info_speed":2048,"free_space_on_disk":4096,` +
+		`"global_ratio":"1.50","last_external_address_v4":"203.0.113.7","last_external_address_v6":"",` +
+		`"read_cache_hits":"12.5","refresh_interval":1500,"total_peer_connections":3,"queueing":true}`
+	if err := json.Unmarshal([]byte(full), &state); err != nil {
+		t.Fatalf("unmarshaling full server state: %v", err)
+	}
+	if state.AllTimeDownload.Bytes() != 1024 || state.FreeSpaceOnDisk.Bytes() != 4096 {
+		t.Fatalf("sizes not converted: alltime_dl=%v free_space_on_disk=%v", state.AllTimeDownload, state.FreeSpaceOnDisk)
+	}
+	if state.GlobalRatio != 1.5 || state.ReadCacheHits != 12.5 {
+		t.Fatalf("string floats not parsed: global_ratio=%v read_cache_hits=%v", state.GlobalRatio, state.ReadCacheHits)
+	}
+	if state.RefreshInterval != 1500*time.Millisecond {
+		t.Fatalf("refresh interval not converted: %v", state.RefreshInterval)
+	}
+	if state.LastExternalAddressV4.String() != "203.0.113.7" || state.LastExternalAddressV6.IsValid() {
+		t.Fatalf("external addresses not parsed: v4=%v v6=%v", state.LastExternalAddressV4, state.LastExternalAddressV6)
+	}
+
+	// ── incremental update ──────────────────────────────────
+	var delta SyncServerState
+	if err := json.Unmarshal([]byte(`{"dl_info_speed":0,"total_peer_connections":5}`), &delta); err != nil {
+		t.Fatalf("unmarshaling delta server state: %v", err)
+	}
+	if !delta.Has("dl_info_speed") || delta.Has("free_space_on_disk") {
+		t.Fatal("delta does not report the fields it was sent correctly")
+	}
+	state.Update(delta)
+	if state.DlInfoSpeed.Bytes() != 0 {
+		t.Fatalf("zero value sent in delta not applied: dl_info_speed=%v", state.DlInfoSpeed)
+	}
+	if state.TotalPeerConnections != 5 {
+		t.Fatalf("delta not applied: total_peer_connections=%d", state.TotalPeerConnections)
+	}
+	if state.FreeSpaceOnDisk.Bytes() != 4096 || state.ConnectionStatus != "connected" || !state.Queueing {
+		t.Fatalf("fields absent from delta were overwritten: %+v", state)
+	}
+
+	// ── JSON round trip ─────────────────────────────────────
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("marshaling server state: %v", err)
+	}
+	var roundTrip SyncServerState
+	if err := json.Unmarshal(data, &roundTrip); err != nil {
+		t.Fatalf("unmarshaling marshaled server state: %v", err)
+	}
+	if roundTrip.GlobalRatio != state.GlobalRatio || roundTrip.LastExternalAddressV4 != state.LastExternalAddressV4 ||
+		roundTrip.FreeSpaceOnDisk != state.FreeSpaceOnDisk || roundTrip.RefreshInterval != state.RefreshInterval {
+		t.Fatalf("round trip mismatch: %+v != %+v", roundTrip, state)
+	}
+}
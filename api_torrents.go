@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"mime/multipart"
+	"net/http"
 	"net/textproto"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"reflect"
 	"strconv"
@@ -25,9 +29,10 @@ import (
 */
 
 const (
-	torrentsAPIName   = "torrents"
-	tagListSeparator  = ","
-	hashListSeparator = "|"
+	torrentsAPIName          = "torrents"
+	tagListSeparator         = ","
+	tagListResponseSeparator = ", " // the server joins tags with a comma and a space
+	hashListSeparator        = "|"
 )
 
 /*
@@ -98,12 +103,28 @@ func (fs FilterState) Ptr() *FilterState {
 	return &fs
 }
 
+// legacyFilterStates contains the filter states renamed by qBittorrent 5.0
+var legacyFilterStates = map[string]string{
+	string(FilterStateStopped): "paused",
+	string(FilterStateRunning): "resumed",
+}
+
 // GetTorrentList returns a torrent listing. filters are optional and can be nil.
+// The stopped and running state filters are sent as paused and resumed to qBittorrent 4.x servers.
 // https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#get-torrent-list
 func (c *Client) GetTorrentList(ctx context.Context, filters *ListFilters) (list []TorrentInfos, err error) {
 	var preparedFilters map[string]string
 	if filters != nil {
 		preparedFilters = filters.getLowLevelRepr()
+		if legacyState, renamed := legacyFilterStates[preparedFilters["filter"]]; renamed {
+			var supported bool
+			if supported, err = c.Supports(ctx, APIFeatureStartStop); err != nil {
+				return
+			}
+			if !supported {
+				preparedFilters["filter"] = legacyState
+			}
+		}
 	}
 	// build request
 	req, err := c.requestBuild(ctx, "GET", torrentsAPIName, "info", preparedFilters, nil)
@@ -138,6 +159,7 @@ type TorrentInfos struct {
 	ForceStart         bool          `json:"force_start"`        // True if force start is enabled for this torrent
 	Hash               string        `json:"hash"`               // Torrent hash
 	Private            bool          `json:"-"`                  // True if torrent is from a private tracker. The wiki documents `isPrivate` but qBittorrent v5+ sends `is_private`.
+	PrivateUnknown     bool          `json:"-"`                  // True if the server did not report the private flag (metadata not downloaded yet or qBittorrent < 5), Private is then false
 	LastActivity       time.Time     `json:"last_activity"`      // Last time when a chunk was downloaded/uploaded
 	MagnetURI          *url.URL      `json:"magnet_uri"`         // Magnet URI corresponding to this torrent (use .Query() to access values)
 	MaxRatio           float64       `json:"max_ratio"`          // Maximum share ratio until torrent is stopped from seeding/uploading
@@ -160,7 +182,7 @@ type TorrentInfos struct {
 	Size               cunits.Bits   `json:"size"`               // Total size of files selected for download
 	State              TorrentState  `json:"state"`              // Torrent state
 	SuperSeeding       bool          `json:"super_seeding"`      // True if super seeding is enabled
-	Tags               []string      `json:"tags"`               // Comma-concatenated tag list of the torrent
+	Tags               []string      `json:"tags"`               // Tag list of the torrent
 	TimeActive         time.Duration `json:"time_active"`        // Total active time
 	TotalSize          cunits.Bits   `json:"total_size"`         // Total size of all file in this torrent (including unselected ones)
 	Tracker            string        `json:"tracker"`            // The first tracker with working status. Returns empty string if no tracker is working.
@@ -199,9 +221,9 @@ func (ti *TorrentInfos) UnmarshalJSON(data []byte) (err error) {
 		Uploaded           int    `json:"uploaded"`           // Amount of data uploaded
 		UploadedSession    int    `json:"uploaded_session"`   // Amount of data uploaded this session
 		UploadSpeed        int    `json:"upspeed"`            // Torrent upload speed (bytes/s)
-		// Unmarshal both `isPrivate` (documented) and `is_private` (qBittorrent v5+) into Private.
-		IsPrivateDoc   bool `json:"isPrivate"`
-		IsPrivateSnake bool `json:"is_private"`
+		// Unmarshal both `isPrivate` (documented) and `is_private` (qBittorrent v5+) into Private, null or absent if unknown.
+		IsPrivateDoc   *bool `json:"isPrivate"`
+		IsPrivateSnake *bool `json:"is_private"`
 	}{
 		mask: (*mask)(ti),
 	}
@@ -210,7 +232,14 @@ func (ti *TorrentInfos) UnmarshalJSON(data []byte) (err error) {
 		return
 	}
 	// Adapt to golang types
-	ti.Private = tmp.IsPrivateDoc || tmp.IsPrivateSnake
+	switch {
+	case tmp.IsPrivateSnake != nil:
+		ti.Private, ti.PrivateUnknown = *tmp.IsPrivateSnake, false
+	case tmp.IsPrivateDoc != nil:
+		ti.Private, ti.PrivateUnknown = *tmp.IsPrivateDoc, false
+	default:
+		ti.Private, ti.PrivateUnknown = false, true
+	}
 	ti.AddedOn = time.Unix(tmp.AddedOn, 0)
 	ti.AmountLeft = cunits.ImportInBytes(float64(tmp.AmountLeft))
 	ti.Completed = cunits.ImportInBytes(float64(tmp.Completed))
@@ -231,12 +260,7 @@ func (ti *TorrentInfos) UnmarshalJSON(data []byte) (err error) {
 	ti.SeedingTimeLimit = time.Duration(tmp.SeedingTimeLimit) * time.Second
 	ti.SeenComplete = time.Unix(tmp.SeenComplete, 0)
 	ti.Size = cunits.ImportInBytes(float64(tmp.Size))
-	if tmp.Tags != "" {
-		ti.Tags = strings.Split(tmp.Tags, tagListSeparator)
-		for i := range ti.Tags {
-			ti.Tags[i] = strings.TrimSpace(ti.Tags[i])
-		}
-	}
+	ti.Tags = parseTagList(tmp.Tags)
 	ti.TimeActive = time.Duration(tmp.TimeActive) * time.Second
 	ti.TotalSize = cunits.ImportInBytes(float64(tmp.TotalSize))
 	ti.UploadSpeedLimit = GetSpeedFromBytes(tmp.UploadSpeedLimit)
@@ -275,11 +299,11 @@ func (ti *TorrentInfos) MarshalJSON() ([]byte, error) {
 		Uploaded           int    `json:"uploaded"`           // Amount of data uploaded
 		UploadedSession    int    `json:"uploaded_session"`   // Amount of data uploaded this session
 		UploadSpeed        int    `json:"upspeed"`            // Torrent upload speed (bytes/s)
-		// Marshal `is_private` to match the wire format used by qBittorrent v5+.
-		IsPrivate bool `json:"is_private"`
+		// Marshal `is_private` to match the wire format used by qBittorrent v5+, null if unknown.
+		IsPrivate *bool `json:"is_private"`
 	}{
 		mask:      mask(*ti),
-		IsPrivate: ti.Private,
+		IsPrivate: optionalPrivateFlag(ti.Private, ti.PrivateUnknown),
 	}
 	// Adapt to JSON types
 	tmp.AddedOn = ti.AddedOn.Unix()
@@ -299,7 +323,7 @@ func (ti *TorrentInfos) MarshalJSON() ([]byte, error) {
 	tmp.SeedingTimeLimit = int(ti.SeedingTimeLimit.Seconds())
 	tmp.SeenComplete = ti.SeenComplete.Unix()
 	tmp.Size = int(ti.Size.Bytes())
-	tmp.Tags = strings.Join(ti.Tags, tagListSeparator)
+	tmp.Tags = strings.Join(ti.Tags, tagListResponseSeparator)
 	tmp.TimeActive = int(ti.TimeActive.Seconds())
 	tmp.TotalSize = int(ti.TotalSize.Bytes())
 	tmp.UploadSpeedLimit = ti.UploadSpeedLimit.ToBytes()
@@ -309,6 +333,14 @@ func (ti *TorrentInfos) MarshalJSON() ([]byte, error) {
 	return json.Marshal(tmp)
 }
 
+// optionalPrivateFlag returns the wire value of a private flag: nil if unknown.
+func optionalPrivateFlag(private, unknown bool) *bool {
+	if unknown {
+		return nil
+	}
+	return &private
+}
+
 type TorrentState string
 
 const (
@@ -392,6 +424,7 @@ type TorrentGenericProperties struct {
 	UploadSpeedAvg         Speed         `json:"up_speed_avg"`             // Torrent average upload speed
 	UploadSpeed            Speed         `json:"up_speed"`                 // Torrent upload speed
 	Private                bool          `json:"-"`                        // True if torrent is from a private tracker. The wiki documents `isPrivate` but qBittorrent v5+ sends `is_private`.
+	PrivateUnknown         bool          `json:"-"`                        // True if the server did not report the private flag (metadata not downloaded yet or qBittorrent < 5), Private is then false
 }
 
 func (tgp *TorrentGenericProperties) UnmarshalJSON(data []byte) (err error) {
@@ -420,9 +453,9 @@ func (tgp *TorrentGenericProperties) UnmarshalJSON(data []byte) (err error) {
 		TotalSize              int   `json:"total_size"`               // Torrent total size (bytes)
 		UploadSpeedAvg         int   `json:"up_speed_avg"`             // Torrent average upload speed (bytes/second)
 		UploadSpeed            int   `json:"up_speed"`                 // Torrent upload speed (bytes/second)
-		// Unmarshal both `isPrivate` (documented) and `is_private` (qBittorrent v5+) into Private.
-		IsPrivateDoc   bool `json:"isPrivate"`
-		IsPrivateSnake bool `json:"is_private"`
+		// Unmarshal both `isPrivate` (documented) and `is_private` (qBittorrent v5+) into Private, null or absent if unknown.
+		IsPrivateDoc   *bool `json:"isPrivate"`
+		IsPrivateSnake *bool `json:"is_private"`
 	}{
 		mask: (*mask)(tgp),
 	}
@@ -431,7 +464,14 @@ func (tgp *TorrentGenericProperties) UnmarshalJSON(data []byte) (err error) {
 		return
 	}
 	// Adapt to golang types
-	tgp.Private = tmp.IsPrivateDoc || tmp.IsPrivateSnake
+	switch {
+	case tmp.IsPrivateSnake != nil:
+		tgp.Private, tgp.PrivateUnknown = *tmp.IsPrivateSnake, false
+	case tmp.IsPrivateDoc != nil:
+		tgp.Private, tgp.PrivateUnknown = *tmp.IsPrivateDoc, false
+	default:
+		tgp.Private, tgp.PrivateUnknown = false, true
+	}
 	tgp.CreationDate = time.Unix(tmp.CreationDate, 0)
 	tgp.PieceSize = cunits.ImportInBytes(float64(tmp.PieceSize))
 	tgp.TotalWasted = cunits.ImportInBytes(float64(tmp.TotalWasted))
@@ -482,11 +522,11 @@ func (tgp *TorrentGenericProperties) MarshalJSON() ([]byte, error) {
 		TotalSize              int   `json:"total_size"`               // Torrent total size (bytes)
 		UploadSpeedAvg         int   `json:"up_speed_avg"`             // Torrent average upload speed (bytes/second)
 		UploadSpeed            int   `json:"up_speed"`                 // Torrent upload speed (bytes/second)
-		// Marshal `is_private` to match the wire format used by qBittorrent v5+.
-		IsPrivate bool `json:"is_private"`
+		// Marshal `is_private` to match the wire format used by qBittorrent v5+, null if unknown.
+		IsPrivate *bool `json:"is_private"`
 	}{
 		mask:                   mask(*tgp),
-		IsPrivate:              tgp.Private,
+		IsPrivate:              optionalPrivateFlag(tgp.Private, tgp.PrivateUnknown),
 		CreationDate:           tgp.CreationDate.Unix(),
 		PieceSize:              int(tgp.PieceSize.Bytes()),
 		TotalWasted:            int(tgp.TotalWasted.Bytes()),
@@ -651,45 +691,260 @@ func ReadTorrentsFiles(paths []string) (content map[string][]byte, err error) {
 	return
 }
 
+// TorrentFileSource is a .torrent file for AddNewTorrentsFromSources(). Its content is only read while the request
+// is being sent, Open being called each time the request body is generated: once more if the request is replayed
+// after an automatic login. As the server requires the request length, the body is only streamed if the Size of
+// all the sources is known, otherwise it is buffered.
+type TorrentFileSource struct {
+	Name string                        // File name, must have the .torrent extension
+	Open func() (io.ReadCloser, error) // Opens the file content
+	Size int64                         // Content size (bytes), 0 if unknown. The content must match it when read.
+}
+
+// TorrentFileFromPath returns a source reading the file at path, its size being the one of the file when called.
+func TorrentFileFromPath(path string) TorrentFileSource {
+	var size int64
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+		size = info.Size()
+	}
+	return TorrentFileSource{
+		Name: filepath.Base(path),
+		Open: func() (io.ReadCloser, error) {
+			return os.Open(path)
+		},
+		Size: size,
+	}
+}
+
+// TorrentFileFromReader returns a source reading r. If r implements io.Seeker, it is rewound to its current
+// position each time the source is opened and its size is known. Otherwise it can only be read once and the
+// request will fail if it has to be replayed after an automatic login.
+func TorrentFileFromReader(name string, r io.Reader) TorrentFileSource {
+	seeker, seekable := r.(io.Seeker)
+	var start, size int64
+	if seekable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			seekable = false
+		} else if end, err := seeker.Seek(0, io.SeekEnd); err == nil {
+			size = end - start
+			if _, err = seeker.Seek(start, io.SeekStart); err != nil {
+				seekable, size = false, 0
+			}
+		}
+	}
+	var consumed bool
+	return TorrentFileSource{
+		Name: name,
+		Open: func() (io.ReadCloser, error) {
+			if seekable {
+				if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+					return nil, fmt.Errorf("rewinding reader failed: %w", err)
+				}
+			} else if consumed {
+				return nil, errors.New("reader is not seekable and has already been consumed")
+			}
+			consumed = true
+			return io.NopCloser(r), nil
+		},
+		Size: size,
+	}
+}
+
+// TorrentFilesFromFS returns a source for each .torrent file found under the root directory of fsys,
+// walking it recursively in lexical order. Files are not opened until the sources are.
+func TorrentFilesFromFS(fsys fs.FS, root string) (sources []TorrentFileSource, err error) {
+	err = fs.WalkDir(fsys, root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || path.Ext(filePath) != ".torrent" {
+			return nil
+		}
+		var size int64
+		if info, err := entry.Info(); err == nil && info.Mode().IsRegular() {
+			size = info.Size()
+		}
+		sources = append(sources, TorrentFileSource{
+			Name: entry.Name(),
+			Open: func() (io.ReadCloser, error) {
+				return fsys.Open(filePath)
+			},
+			Size: size,
+		})
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("walking %q failed: %w", root, err)
+	}
+	return
+}
+
+// TorrentContentLayout defines how the content of a new torrent is laid out on disk.
+type TorrentContentLayout string
+
+const (
+	TorrentContentLayoutOriginal    TorrentContentLayout = "Original"    // Keep the torrent layout
+	TorrentContentLayoutSubfolder   TorrentContentLayout = "Subfolder"   // Always create a root folder
+	TorrentContentLayoutNoSubfolder TorrentContentLayout = "NoSubfolder" // Never create a root folder
+)
+
+func (tcl TorrentContentLayout) Ptr() *TorrentContentLayout {
+	return &tcl
+}
+
+// TorrentStopCondition defines when a new torrent is automatically stopped.
+type TorrentStopCondition string
+
+const (
+	TorrentStopConditionNone             TorrentStopCondition = "None"             // Never stopped automatically
+	TorrentStopConditionMetadataReceived TorrentStopCondition = "MetadataReceived" // Stopped once its metadata are received
+	TorrentStopConditionFilesChecked     TorrentStopCondition = "FilesChecked"     // Stopped once its files are checked
+)
+
+func (tsc TorrentStopCondition) Ptr() *TorrentStopCondition {
+	return &tsc
+}
+
+// TorrentOperatingMode defines if a new torrent is subject to the queueing system.
+type TorrentOperatingMode string
+
+const (
+	TorrentOperatingModeAutoManaged TorrentOperatingMode = "AutoManaged" // Subject to the queueing system
+	TorrentOperatingModeForced      TorrentOperatingMode = "Forced"      // Forced, ignoring the queueing system
+)
+
+func (tom TorrentOperatingMode) Ptr() *TorrentOperatingMode {
+	return &tom
+}
+
+// ShareLimitAction defines the action applied to a torrent reaching its share limits.
+type ShareLimitAction string
+
+const (
+	ShareLimitActionDefault            ShareLimitAction = "Default"            // Use the global setting
+	ShareLimitActionStop               ShareLimitAction = "Stop"               // Stop the torrent
+	ShareLimitActionRemove             ShareLimitAction = "Remove"             // Remove the torrent
+	ShareLimitActionRemoveWithContent  ShareLimitAction = "RemoveWithContent"  // Remove the torrent and its files
+	ShareLimitActionEnableSuperSeeding ShareLimitAction = "EnableSuperSeeding" // Enable super seeding
+)
+
+func (sla ShareLimitAction) Ptr() *ShareLimitAction {
+	return &sla
+}
+
 // AddNewTorrentsOptions holds options for adding new torrents.
+// Options whose name changed across API versions are sent under the name expected by the server.
 type AddNewTorrentsOptions struct {
-	SavePath               *string        // Download folder
-	Category               *string        // Category for the torrent
-	Tags                   []string       // Tags for the torrent
-	SkipChecking           *bool          // Skip hash checking
-	Paused                 *bool          // Add torrents in the paused state
-	RootFolder             *bool          // Create the root folder
-	Rename                 *string        // Rename torrent
-	UploadLimit            *Speed         // Set torrent upload speed limit (/sec)
-	DownloadLimit          *Speed         // Set torrent download speed limit (/sec)
-	RatioLimit             *float64       // Set torrent share ratio limit (since 2.8.1)
-	SeedingTimeLimit       *time.Duration // Set torrent seeding time limit (since 2.8.1) (will be converted to minutes)
-	AutoTMM                *bool          // Whether Automatic Torrent Management should be used
-	SequentialDownload     *bool          // Enable sequential download
-	FirstLastPiecePriority *bool          // Prioritize download first last piece
+	SavePath                 *string               // Download folder
+	DownloadPath             *string               // Incomplete torrents folder
+	UseDownloadPath          *bool                 // Whether DownloadPath should be used
+	Category                 *string               // Category for the torrent
+	Tags                     []string              // Tags for the torrent
+	SkipChecking             *bool                 // Skip hash checking
+	Stopped                  *bool                 // Add torrents in the stopped state (sent as "paused" before API 2.11.0)
+	StopCondition            *TorrentStopCondition // Stop the torrent once the condition is reached
+	AddToTopOfQueue          *bool                 // Add torrents to the top of the queue
+	Forced                   *bool                 // Add torrents in forced mode, ignoring the queueing system
+	ContentLayout            *TorrentContentLayout // Content layout (sent as "root_folder" before API 2.7.0)
+	Rename                   *string               // Rename torrent
+	UploadLimit              *Speed                // Set torrent upload speed limit (/sec)
+	DownloadLimit            *Speed                // Set torrent download speed limit (/sec)
+	RatioLimit               *float64              // Set torrent share ratio limit (since 2.8.1)
+	SeedingTimeLimit         *time.Duration        // Set torrent seeding time limit (since 2.8.1) (will be converted to minutes)
+	InactiveSeedingTimeLimit *time.Duration        // Set torrent inactive seeding time limit (will be converted to minutes)
+	ShareLimitAction         *ShareLimitAction     // Action applied once a share limit is reached
+	AutoTMM                  *bool                 // Whether Automatic Torrent Management should be used
+	SequentialDownload       *bool                 // Enable sequential download
+	FirstLastPiecePriority   *bool                 // Prioritize download first last piece
+	Cookies                  []*http.Cookie        // Cookies sent to download the torrents URLs (only name and value are used)
+	// Deprecated: use Stopped. Used if Stopped is nil.
+	Paused *bool
+	// Deprecated: use ContentLayout. Used if ContentLayout is nil: true is Subfolder, false is NoSubfolder.
+	RootFolder *bool
+}
+
+// stopped returns the stopped state to send, handling the deprecated Paused field.
+func (anto *AddNewTorrentsOptions) stopped() *bool {
+	if anto.Stopped != nil {
+		return anto.Stopped
+	}
+	return anto.Paused
+}
+
+// contentLayout returns the content layout to send, handling the deprecated RootFolder field.
+func (anto *AddNewTorrentsOptions) contentLayout() *TorrentContentLayout {
+	switch {
+	case anto.ContentLayout != nil:
+		return anto.ContentLayout
+	case anto.RootFolder == nil:
+		return nil
+	case *anto.RootFolder:
+		return TorrentContentLayoutSubfolder.Ptr()
+	default:
+		return TorrentContentLayoutNoSubfolder.Ptr()
+	}
+}
+
+// versionDependent returns true if the options contain fields whose name depends on the API version.
+func (anto *AddNewTorrentsOptions) versionDependent() bool {
+	return anto.stopped() != nil || anto.contentLayout() != nil
 }
 
 // AddNewTorrents adds new torrents. There must be at least one file content or URL.
-// Check the ReadTorrentsFiles() helper for files content. options can be nil.
+// Check the ReadTorrentsFiles() helper for files content, files being sent in name order.
+// AddNewTorrentsFromSources() allows to stream files instead of loading them in memory. options can be nil.
 // https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#add-new-torrent
 func (c *Client) AddNewTorrents(ctx context.Context, files map[string][]byte, urls []*url.URL, options *AddNewTorrentsOptions) (err error) {
+	sources := make([]TorrentFileSource, 0, len(files))
+	for _, filename := range sortedKeys(files) {
+		sources = append(sources, TorrentFileFromReader(filename, bytes.NewReader(files[filename])))
+	}
+	return c.AddNewTorrentsFromSources(ctx, sources, urls, options)
+}
+
+// AddNewTorrentsFromSources adds new torrents, streaming the files content in the order of the files slice.
+// There must be at least one file or URL. Check the TorrentFileFromPath(), TorrentFileFromReader() and
+// TorrentFilesFromFS() helpers for files. options can be nil.
+// https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#add-new-torrent
+func (c *Client) AddNewTorrentsFromSources(ctx context.Context, files []TorrentFileSource, urls []*url.URL, options *AddNewTorrentsOptions) (err error) {
 	if len(files) == 0 && len(urls) == 0 {
 		err = fmt.Errorf("no files or URLs provided")
 		return
 	}
-	// build payload
-	payload, contentType, err := torrentAddGeneratePayload(files, urls, options)
+	// get the API version if options depend on it
+	var apiVersion APIVersion
+	if options != nil && options.versionDependent() {
+		if apiVersion, err = c.ServerAPIVersion(ctx); err != nil {
+			return
+		}
+	}
+	// prepare payload
+	payload, err := newTorrentAddPayload(files, urls, options, apiVersion)
+	if err != nil {
+		err = fmt.Errorf("generating payload failed: %w", err)
+		return
+	}
+	openBody, length, err := payload.body()
+	if err != nil {
+		err = fmt.Errorf("generating payload failed: %w", err)
+		return
+	}
+	body, err := openBody()
 	if err != nil {
 		err = fmt.Errorf("generating payload failed: %w", err)
 		return
 	}
 	// build request
-	req, err := c.requestBuild(ctx, "POST", torrentsAPIName, "add", nil, &payload)
+	req, err := c.requestBuild(ctx, "POST", torrentsAPIName, "add", nil, body)
 	if err != nil {
+		body.Close()
 		err = fmt.Errorf("building request failed: %w", err)
 		return
 	}
-	req.Header.Set(contentTypeHeader, contentType)
+	req.ContentLength = length // the server does not support chunked bodies
+	req.GetBody = openBody     // allows to replay the request after an automatic login
+	req.Header.Set(contentTypeHeader, payload.contentType())
 	// execute request
 	// output is nil because the server response format varies across versions:
 	// some return text/plain ("Ok."), others application/json. Relying on the
@@ -794,14 +1049,42 @@ func (c *Client) GetTorrentContents(ctx context.Context, hash string, indexes []
 
 // TorrentContent represents a single file within a torrent.
 type TorrentContent struct {
-	Index        int     `json:"index"`        // File index
-	Name         string  `json:"name"`         // File name (including relative path)
-	Size         int64   `json:"size"`         // File size (bytes)
-	Progress     float64 `json:"progress"`     // File progress (percentage/100)
-	Priority     int     `json:"priority"`     // File priority
-	IsSeed       bool    `json:"is_seed"`      // True if file is seeding/complete
-	PieceRange   []int   `json:"piece_range"`  // First and last piece index (inclusive)
-	Availability float64 `json:"availability"` // Percentage of file pieces currently available
+	Index        int          `json:"index"`        // File index
+	Name         string       `json:"name"`         // File name (including relative path)
+	Size         int64        `json:"size"`         // File size (bytes)
+	Progress     float64      `json:"progress"`     // File progress (percentage/100)
+	Priority     FilePriority `json:"priority"`     // File priority
+	IsSeed       bool         `json:"is_seed"`      // True if file is seeding/complete
+	PieceRange   []int        `json:"piece_range"`  // First and last piece index (inclusive)
+	Availability float64      `json:"availability"` // Percentage of file pieces currently available
+}
+
+// FilePriority represents the download priority of a torrent file.
+type FilePriority int
+
+const (
+	FilePriorityMixed         FilePriority = -1 // Not a real priority: directory containing files with different priorities (see TorrentContentNode)
+	FilePriorityDoNotDownload FilePriority = 0  // Do not download
+	FilePriorityNormal        FilePriority = 1  // Normal priority
+	FilePriorityHigh          FilePriority = 6  // High priority
+	FilePriorityMaximal       FilePriority = 7  // Maximal priority
+)
+
+func (fp FilePriority) String() string {
+	switch fp {
+	case FilePriorityMixed:
+		return "Mixed"
+	case FilePriorityDoNotDownload:
+		return "DoNotDownload"
+	case FilePriorityNormal:
+		return "Normal"
+	case FilePriorityHigh:
+		return "High"
+	case FilePriorityMaximal:
+		return "Maximal"
+	default:
+		return "Unknown"
+	}
 }
 
 /*
@@ -848,10 +1131,20 @@ func (c *Client) GetTorrentPiecesHashes(ctx context.Context, hash string) (hashe
 	pause torrents
 */
 
-// StopTorrents pauses one or more torrents.
+// StopTorrents pauses one or more torrents (using the legacy pause endpoint on qBittorrent 4.x servers).
 // https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#pause-torrents
 func (c *Client) StopTorrents(ctx context.Context, hashes []string) (err error) {
-	req, err := c.requestBuild(ctx, "POST", torrentsAPIName, "stop", map[string]string{
+	// qBittorrent 4.x servers use the legacy endpoint
+	method := "stop"
+	supported, err := c.Supports(ctx, APIFeatureStartStop)
+	if err != nil {
+		err = fmt.Errorf("getting server API version failed: %w", err)
+		return
+	}
+	if !supported {
+		method = "pause"
+	}
+	req, err := c.requestBuild(ctx, "POST", torrentsAPIName, method, map[string]string{
 		"hashes": strings.Join(hashes, hashListSeparator),
 	}, nil)
 	if err != nil {
@@ -868,10 +1161,20 @@ func (c *Client) StopTorrents(ctx context.Context, hashes []string) (err error)
 	resume torrents
 */
 
-// StartTorrents resumes one or more torrents.
+// StartTorrents resumes one or more torrents (using the legacy resume endpoint on qBittorrent 4.x servers).
 // https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#resume-torrents
 func (c *Client) StartTorrents(ctx context.Context, hashes []string) (err error) {
-	req, err := c.requestBuild(ctx, "POST", torrentsAPIName, "start", map[string]string{
+	// qBittorrent 4.x servers use the legacy endpoint
+	method := "start"
+	supported, err := c.Supports(ctx, APIFeatureStartStop)
+	if err != nil {
+		err = fmt.Errorf("getting server API version failed: %w", err)
+		return
+	}
+	if !supported {
+		method = "resume"
+	}
+	req, err := c.requestBuild(ctx, "POST", torrentsAPIName, method, map[string]string{
 		"hashes": strings.Join(hashes, hashListSeparator),
 	}, nil)
 	if err != nil {
@@ -1344,10 +1647,55 @@ func (c *Client) SetTorrentCategory(ctx context.Context, hashes []string, catego
 	get all categories
 */
 
-// Category represents a torrent category.
+// Category represents a torrent category. Subcategories are named after their parent ("parent/child"),
+// see NewCategoryTree().
 type Category struct {
-	Name     string `json:"name"`     // Category name
-	SavePath string `json:"savePath"` // Category save path
+	Name         string                `json:"name"`          // Category name
+	SavePath     string                `json:"savePath"`      // Category save path (empty means the default save path followed by the category name)
+	DownloadPath *CategoryDownloadPath `json:"download_path"` // Category download path, nil if the category follows the global setting
+}
+
+// CategoryDownloadPath is the download path (incomplete torrents location) setting of a category. Sent by the
+// server as null (follows the global setting, nil CategoryDownloadPath), false (disabled) or the path (enabled).
+type CategoryDownloadPath struct {
+	Enabled bool   // Use a download path for the category torrents
+	Path    string // Download path if enabled (empty means the default download path followed by the category name)
+}
+
+func (cdp *CategoryDownloadPath) UnmarshalJSON(data []byte) (err error) {
+	var raw any
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return
+	}
+	switch typed := raw.(type) {
+	case bool:
+		*cdp = CategoryDownloadPath{Enabled: typed}
+	case string:
+		*cdp = CategoryDownloadPath{Enabled: true, Path: typed}
+	case nil:
+		*cdp = CategoryDownloadPath{}
+	default:
+		err = fmt.Errorf("unexpected category download path: %s", data)
+	}
+	return
+}
+
+func (cdp CategoryDownloadPath) MarshalJSON() ([]byte, error) {
+	if !cdp.Enabled {
+		return json.Marshal(false)
+	}
+	return json.Marshal(cdp.Path)
+}
+
+// params returns the download path parameters of category creation and edition, none if cdp is nil.
+func (cdp *CategoryDownloadPath) params(params map[string]string) {
+	if cdp == nil {
+		return
+	}
+	params["downloadPathEnabled"] = strconv.FormatBool(cdp.Enabled)
+	if cdp.Enabled {
+		params["downloadPath"] = cdp.Path
+	}
 }
 
 // GetAllCategories returns all categories.
@@ -1368,13 +1716,16 @@ func (c *Client) GetAllCategories(ctx context.Context) (categories map[string]Ca
 	add new category
 */
 
-// CreateCategory creates a new category.
+// CreateCategory creates a new category (name can be "parent/child" for a subcategory). downloadPath
+// can be nil for the category to follow the global download path setting.
 // https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#create-category
-func (c *Client) CreateCategory(ctx context.Context, category string, savePath string) (err error) {
-	req, err := c.requestBuild(ctx, "POST", torrentsAPIName, "createCategory", map[string]string{
+func (c *Client) CreateCategory(ctx context.Context, category string, savePath string, downloadPath *CategoryDownloadPath) (err error) {
+	params := map[string]string{
 		"category": category,
 		"savePath": savePath,
-	}, nil)
+	}
+	downloadPath.params(params)
+	req, err := c.requestBuild(ctx, "POST", torrentsAPIName, "createCategory", params, nil)
 	if err != nil {
 		err = fmt.Errorf("building request failed: %w", err)
 		return
@@ -1389,13 +1740,16 @@ func (c *Client) CreateCategory(ctx context.Context, category string, savePath s
 	edit category
 */
 
-// EditCategory edits an existing category.
+// EditCategory edits an existing category. All the settings are replaced: downloadPath must be passed
+// again to be kept, nil making the category follow the global download path setting.
 // https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#edit-category
-func (c *Client) EditCategory(ctx context.Context, category string, savePath string) (err error) {
-	req, err := c.requestBuild(ctx, "POST", torrentsAPIName, "editCategory", map[string]string{
+func (c *Client) EditCategory(ctx context.Context, category string, savePath string, downloadPath *CategoryDownloadPath) (err error) {
+	params := map[string]string{
 		"category": category,
 		"savePath": savePath,
-	}, nil)
+	}
+	downloadPath.params(params)
+	req, err := c.requestBuild(ctx, "POST", torrentsAPIName, "editCategory", params, nil)
 	if err != nil {
 		err = fmt.Errorf("building request failed: %w", err)
 		return
@@ -1526,6 +1880,27 @@ func (c *Client) RemoveTorrentTags(ctx context.Context, hashes []string, tags []
 	return
 }
 
+/*
+	set torrent tags
+*/
+
+// setTorrentTags replaces the tags of one or more torrents (qBittorrent 5.1 and later, see APIFeatureSetTags).
+// See SetTorrentTags() which falls back to tags diffs on older servers.
+func (c *Client) setTorrentTags(ctx context.Context, hashes []string, tags []string) (err error) {
+	req, err := c.requestBuild(ctx, "POST", torrentsAPIName, "setTags", map[string]string{
+		"hashes": strings.Join(hashes, hashListSeparator),
+		"tags":   strings.Join(tags, tagListSeparator),
+	}, nil)
+	if err != nil {
+		err = fmt.Errorf("building request failed: %w", err)
+		return
+	}
+	if err = c.requestExecute(req, nil, true); err != nil {
+		err = fmt.Errorf("executing request failed: %w", err)
+	}
+	return
+}
+
 /*
 	set torrent name
 */
@@ -1597,7 +1972,7 @@ func (c *Client) RenameFolder(ctx context.Context, hash string, oldPath string,
 
 // SetFilePriority sets the priority for files in a torrent.
 // https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#set-file-priority
-func (c *Client) SetFilePriority(ctx context.Context, hash string, ids []int, priority int) (err error) {
+func (c *Client) SetFilePriority(ctx context.Context, hash string, ids []int, priority FilePriority) (err error) {
 	idStrs := make([]string, len(ids))
 	for i, id := range ids {
 		idStrs[i] = strconv.Itoa(id)
@@ -1605,7 +1980,7 @@ func (c *Client) SetFilePriority(ctx context.Context, hash string, ids []int, pr
 	req, err := c.requestBuild(ctx, "POST", torrentsAPIName, "filePrio", map[string]string{
 		"hash":     hash,
 		"id":       strings.Join(idStrs, hashListSeparator),
-		"priority": strconv.Itoa(priority),
+		"priority": strconv.Itoa(int(priority)),
 	}, nil)
 	if err != nil {
 		err = fmt.Errorf("building request failed: %w", err)
@@ -1621,37 +1996,28 @@ func (c *Client) SetFilePriority(ctx context.Context, hash string, ids []int, pr
 	new torrents payload helper
 */
 
-func torrentAddGeneratePayload(files map[string][]byte, urls []*url.URL, options *AddNewTorrentsOptions) (payload bytes.Buffer, contentType string, err error) {
-	mp := multipart.NewWriter(&payload)
-	defer func() {
-		if err == nil {
-			if err = mp.Close(); err != nil {
-				err = fmt.Errorf("closing multipart writer failed: %w", err)
-			}
-		}
-	}()
-	contentType = mp.FormDataContentType()
-	// Add raw files
-	var mpw io.Writer
-	for filename, content := range files {
-		if filepath.Ext(filename) != ".torrent" {
-			err = fmt.Errorf("file %q is not a .torrent file", filename)
-			return
-		}
-		if len(content) == 0 {
-			err = fmt.Errorf("file %q is empty", filename)
-			return
-		}
-		if mpw, err = createBtFormFile(mp, filename); err != nil {
-			err = fmt.Errorf("creating form file %s failed: %w", filename, err)
+// torrentAddPayload generates the multipart body of an add request, streaming the files content if their size is known.
+type torrentAddPayload struct {
+	boundary   string // fixed so the body can be generated again with the same content type
+	files      []TorrentFileSource
+	urls       string
+	options    *AddNewTorrentsOptions
+	apiVersion APIVersion // API version of the server, only needed if options are version dependent
+}
+
+func newTorrentAddPayload(files []TorrentFileSource, urls []*url.URL, options *AddNewTorrentsOptions, apiVersion APIVersion) (payload torrentAddPayload, err error) {
+	// Check files
+	for _, file := range files {
+		if filepath.Ext(file.Name) != ".torrent" {
+			err = fmt.Errorf("file %q is not a .torrent file", file.Name)
 			return
 		}
-		if _, err = mpw.Write(content); err != nil {
-			err = fmt.Errorf("writing file %q content failed: %w", filename, err)
+		if file.Open == nil {
+			err = fmt.Errorf("file %q has no content opener", file.Name)
 			return
 		}
 	}
-	// Add URLs
+	// Check URLs
 	strURLs := make([]string, len(urls))
 	for index, tURL := range urls {
 		// check URL
@@ -1694,20 +2060,153 @@ func torrentAddGeneratePayload(files map[string][]byte, urls []*url.URL, options
 		}
 		strURLs[index] = tURL.String()
 	}
-	if err = mp.WriteField("urls", strings.Join(strURLs, "\n")); err != nil {
+	payload = torrentAddPayload{
+		boundary:   multipart.NewWriter(io.Discard).Boundary(),
+		files:      files,
+		urls:       strings.Join(strURLs, "\n"),
+		options:    options,
+		apiVersion: apiVersion,
+	}
+	return
+}
+
+func (tap torrentAddPayload) contentType() string {
+	return "multipart/form-data; boundary=" + tap.boundary
+}
+
+// body returns the opener of the request body and its length. The body is streamed if the size of all the
+// files is known, the length being the one of the multipart structure (fixed as the boundary is) plus the
+// files sizes. Otherwise the body is buffered to get its length.
+func (tap torrentAddPayload) body() (open func() (io.ReadCloser, error), length int64, err error) {
+	if length = tap.streamedLength(); length >= 0 {
+		open = tap.open
+		return
+	}
+	var buffer bytes.Buffer
+	if err = tap.write(&buffer, true); err != nil {
+		return
+	}
+	content := buffer.Bytes()
+	length = int64(len(content))
+	open = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+	return
+}
+
+// streamedLength returns the length of the body or -1 if the size of a file is unknown.
+func (tap torrentAddPayload) streamedLength() (length int64) {
+	for _, file := range tap.files {
+		if file.Size <= 0 {
+			return -1
+		}
+		length += file.Size
+	}
+	var overhead byteCounter
+	if tap.write(&overhead, false) != nil {
+		return -1
+	}
+	return length + int64(overhead)
+}
+
+// byteCounter is a writer counting the bytes written to it.
+type byteCounter int64
+
+func (bc *byteCounter) Write(p []byte) (int, error) {
+	*bc += byteCounter(len(p))
+	return len(p), nil
+}
+
+// open returns a new body reader, the body being written by a goroutine as it is read.
+func (tap torrentAddPayload) open() (io.ReadCloser, error) {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(tap.write(writer, true))
+	}()
+	return reader, nil
+}
+
+// write writes the multipart body to w, without the files content if withContent is false.
+func (tap torrentAddPayload) write(w io.Writer, withContent bool) (err error) {
+	mp := multipart.NewWriter(w)
+	if err = mp.SetBoundary(tap.boundary); err != nil {
+		err = fmt.Errorf("setting multipart boundary failed: %w", err)
+		return
+	}
+	// Add raw files
+	for _, file := range tap.files {
+		if err = torrentAddWriteFile(mp, file, withContent); err != nil {
+			return
+		}
+	}
+	// Add URLs
+	if err = mp.WriteField("urls", tap.urls); err != nil {
 		err = fmt.Errorf("writing urls to form field failed: %w", err)
 		return
 	}
 	// Handles options
-	if options == nil {
+	if tap.options != nil {
+		if err = torrentAddWriteOptions(mp, tap.options, tap.apiVersion); err != nil {
+			return
+		}
+	}
+	if err = mp.Close(); err != nil {
+		err = fmt.Errorf("closing multipart writer failed: %w", err)
+	}
+	return
+}
+
+func torrentAddWriteFile(mp *multipart.Writer, file TorrentFileSource, withContent bool) (err error) {
+	if !withContent {
+		if _, err = createBtFormFile(mp, file.Name); err != nil {
+			err = fmt.Errorf("creating form file %s failed: %w", file.Name, err)
+		}
+		return
+	}
+	content, err := file.Open()
+	if err != nil {
+		err = fmt.Errorf("opening file %q failed: %w", file.Name, err)
+		return
+	}
+	defer content.Close()
+	mpw, err := createBtFormFile(mp, file.Name)
+	if err != nil {
+		err = fmt.Errorf("creating form file %s failed: %w", file.Name, err)
+		return
+	}
+	written, err := io.Copy(mpw, content)
+	if err != nil {
+		err = fmt.Errorf("writing file %q content failed: %w", file.Name, err)
 		return
 	}
+	switch {
+	case written == 0:
+		err = fmt.Errorf("file %q is empty", file.Name)
+	case file.Size > 0 && written != file.Size:
+		err = fmt.Errorf("file %q content is %d bytes while its size is %d bytes", file.Name, written, file.Size)
+	}
+	return
+}
+
+func torrentAddWriteOptions(mp *multipart.Writer, options *AddNewTorrentsOptions, apiVersion APIVersion) (err error) {
 	if options.SavePath != nil {
 		if err = mp.WriteField("savepath", *options.SavePath); err != nil {
 			err = fmt.Errorf("writing savepath to form field failed: %w", err)
 			return
 		}
 	}
+	if options.DownloadPath != nil {
+		if err = mp.WriteField("downloadPath", *options.DownloadPath); err != nil {
+			err = fmt.Errorf("writing downloadPath to form field failed: %w", err)
+			return
+		}
+	}
+	if options.UseDownloadPath != nil {
+		if err = mp.WriteField("useDownloadPath", strconv.FormatBool(*options.UseDownloadPath)); err != nil {
+			err = fmt.Errorf("writing useDownloadPath to form field failed: %w", err)
+			return
+		}
+	}
 	if options.Category != nil {
 		if err = mp.WriteField("category", *options.Category); err != nil {
 			err = fmt.Errorf("writing category to form field failed: %w", err)
@@ -1726,18 +2225,48 @@ func torrentAddGeneratePayload(files map[string][]byte, urls []*url.URL, options
 			return
 		}
 	}
-	if options.Paused != nil {
-		if err = mp.WriteField("paused", strconv.FormatBool(*options.Paused)); err != nil {
-			err = fmt.Errorf("writing paused to form field failed: %w", err)
+	if stopped := options.stopped(); stopped != nil {
+		field := "stopped"
+		if !apiVersion.Supports(APIFeatureStartStop) {
+			field = "paused"
+		}
+		if err = mp.WriteField(field, strconv.FormatBool(*stopped)); err != nil {
+			err = fmt.Errorf("writing %s to form field failed: %w", field, err)
+			return
+		}
+	}
+	if options.StopCondition != nil {
+		if err = mp.WriteField("stopCondition", string(*options.StopCondition)); err != nil {
+			err = fmt.Errorf("writing stopCondition to form field failed: %w", err)
+			return
+		}
+	}
+	if options.AddToTopOfQueue != nil {
+		if err = mp.WriteField("addToTopOfQueue", strconv.FormatBool(*options.AddToTopOfQueue)); err != nil {
+			err = fmt.Errorf("writing addToTopOfQueue to form field failed: %w", err)
 			return
 		}
 	}
-	if options.RootFolder != nil {
-		if err = mp.WriteField("root_folder", strconv.FormatBool(*options.RootFolder)); err != nil {
-			err = fmt.Errorf("writing root_folder to form field failed: %w", err)
+	if options.Forced != nil {
+		if err = mp.WriteField("forced", strconv.FormatBool(*options.Forced)); err != nil {
+			err = fmt.Errorf("writing forced to form field failed: %w", err)
 			return
 		}
 	}
+	if contentLayout := options.contentLayout(); contentLayout != nil {
+		if apiVersion.Supports(APIFeatureAddContentLayout) {
+			if err = mp.WriteField("contentLayout", string(*contentLayout)); err != nil {
+				err = fmt.Errorf("writing contentLayout to form field failed: %w", err)
+				return
+			}
+		} else if *contentLayout != TorrentContentLayoutOriginal {
+			// the original layout was the default
+			if err = mp.WriteField("root_folder", strconv.FormatBool(*contentLayout == TorrentContentLayoutSubfolder)); err != nil {
+				err = fmt.Errorf("writing root_folder to form field failed: %w", err)
+				return
+			}
+		}
+	}
 	if options.Rename != nil {
 		if err = mp.WriteField("rename", *options.Rename); err != nil {
 			err = fmt.Errorf("writing rename to form field failed: %w", err)
@@ -1768,6 +2297,18 @@ func torrentAddGeneratePayload(files map[string][]byte, urls []*url.URL, options
 			return
 		}
 	}
+	if options.InactiveSeedingTimeLimit != nil {
+		if err = mp.WriteField("inactiveSeedingTimeLimit", strconv.Itoa(int(options.InactiveSeedingTimeLimit.Minutes()))); err != nil {
+			err = fmt.Errorf("writing inactiveSeedingTimeLimit to form field failed: %w", err)
+			return
+		}
+	}
+	if options.ShareLimitAction != nil {
+		if err = mp.WriteField("shareLimitAction", string(*options.ShareLimitAction)); err != nil {
+			err = fmt.Errorf("writing shareLimitAction to form field failed: %w", err)
+			return
+		}
+	}
 	if options.AutoTMM != nil {
 		if err = mp.WriteField("autoTMM", strconv.FormatBool(*options.AutoTMM)); err != nil {
 			err = fmt.Errorf("writing autoTMM to form field failed: %w", err)
@@ -1786,5 +2327,15 @@ func torrentAddGeneratePayload(files map[string][]byte, urls []*url.URL, options
 			return
 		}
 	}
+	if len(options.Cookies) > 0 {
+		cookies := make([]string, len(options.Cookies))
+		for index, cookie := range options.Cookies {
+			cookies[index] = cookie.Name + "=" + cookie.Value
+		}
+		if err = mp.WriteField("cookie", strings.Join(cookies, "; ")); err != nil {
+			err = fmt.Errorf("writing cookie to form field failed: %w", err)
+			return
+		}
+	}
 	return
 }
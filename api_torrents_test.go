@@ -1,14 +1,20 @@
 package qbtapi
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 func TestTorrentsDomain(t *testing.T) {
@@ -106,7 +112,7 @@ func TestTorrentsDomain(t *testing.T) {
 
 		// add paused so we don't actually download anything
 		if err := c.AddNewTorrents(ctx, nil, []*url.URL{magnet}, &AddNewTorrentsOptions{
-			Paused: Bool(true),
+			Stopped: Bool(true),
 		}); err != nil {
 			var httpErr HTTPError
 			if errors.As(err, &httpErr) && httpErr == 409 {
@@ -187,7 +193,7 @@ func TestTorrentsDomain(t *testing.T) {
 		// restore the torrent if it was pre-existing
 		if wasPreExisting {
 			if err := c.AddNewTorrents(ctx, nil, []*url.URL{magnet}, &AddNewTorrentsOptions{
-				Paused: Bool(true),
+				Stopped: Bool(true),
 			}); err != nil {
 				t.Logf("restoring pre-existing torrent failed: %v", err)
 			}
@@ -236,7 +242,7 @@ func TestTorrentsDomain(t *testing.T) {
 
 		// add paused
 		if err := c.AddNewTorrents(ctx, files, nil, &AddNewTorrentsOptions{
-			Paused: Bool(true),
+			Stopped: Bool(true),
 		}); err != nil {
 			var httpErr HTTPError
 			if errors.As(err, &httpErr) && httpErr == 409 {
@@ -436,7 +442,7 @@ func TestTorrentsDomain(t *testing.T) {
 		// category lifecycle
 		catName := "testcat_" + testHash[:8]
 		catPath := t.TempDir()
-		if err := c.CreateCategory(ctx, catName, catPath); err != nil {
+		if err := c.CreateCategory(ctx, catName, catPath, nil); err != nil {
 			t.Fatalf("CreateCategory: %v", err)
 		}
 		if err := c.SetTorrentCategory(ctx, []string{testHash}, catName); err != nil {
@@ -450,14 +456,14 @@ func TestTorrentsDomain(t *testing.T) {
 			t.Fatalf("category filter: expected 1 torrent with hash %s, got %+v", testHash, listByCat)
 		}
 		newCatPath := t.TempDir()
-		if err := c.EditCategory(ctx, catName, newCatPath); err != nil {
+		if err := c.EditCategory(ctx, catName, newCatPath, &CategoryDownloadPath{Enabled: false}); err != nil {
 			t.Fatalf("EditCategory: %v", err)
 		}
 		cats, err := c.GetAllCategories(ctx)
 		if err != nil {
 			t.Fatalf("GetAllCategories: %v", err)
 		}
-		if cat, ok := cats[catName]; !ok || cat.SavePath != newCatPath {
+		if cat, ok := cats[catName]; !ok || cat.SavePath != newCatPath || cat.DownloadPath == nil || cat.DownloadPath.Enabled {
 			t.Fatalf("category edit not reflected: got %+v", cat)
 		}
 		if err := c.RemoveCategories(ctx, []string{catName}); err != nil {
@@ -608,7 +614,7 @@ func TestTorrentsDomain(t *testing.T) {
 		// file priority
 		if len(contents) > 0 {
 			firstID := contents[0].Index
-			if err := c.SetFilePriority(ctx, testHash, []int{firstID}, 7); err != nil {
+			if err := c.SetFilePriority(ctx, testHash, []int{firstID}, FilePriorityMaximal); err != nil {
 				t.Fatalf("SetFilePriority: %v", err)
 			}
 			contentsAfter, err := c.GetTorrentContents(ctx, testHash, nil)
@@ -619,7 +625,7 @@ func TestTorrentsDomain(t *testing.T) {
 			for _, f := range contentsAfter {
 				if f.Index == firstID {
 					found = true
-					if f.Priority != 7 {
+					if f.Priority != FilePriorityMaximal {
 						t.Fatalf("file priority not reflected: expected 7, got %d", f.Priority)
 					}
 					break
@@ -768,3 +774,247 @@ func validateTorrentTracker(t *testing.T, tr TorrentTracker, label string) {
 		t.Fatalf("%s: URL is empty", label)
 	}
 }
+
+func TestAddNewTorrentsStreaming(t *testing.T) {
+	// ── fake instance requiring a login ─────────────────────
+	var (
+		access   sync.Mutex
+		loggedIn bool
+		uploads  [][]string // file names then urls of each accepted upload
+		attempts int
+		chunked  int // requests without Content-Length
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		access.Lock()
+		loggedIn = true
+		access.Unlock()
+		w.Header().Set(contentTypeHeader, contentTypeHeaderTextPlain)
+		_, _ = w.Write([]byte(expectedSuccessResponse))
+	})
+	mux.HandleFunc("/api/v2/torrents/add", func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength <= 0 || r.TransferEncoding != nil {
+			// qBittorrent rejects chunked bodies
+			access.Lock()
+			chunked++
+			access.Unlock()
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reader, err := r.MultipartReader()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var fields []string
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			content, _ := io.ReadAll(part)
+			if part.FormName() == "torrents" {
+				fields = append(fields, part.FileName()+"="+string(content))
+			} else {
+				fields = append(fields, part.FormName()+"="+string(content))
+			}
+		}
+		access.Lock()
+		defer access.Unlock()
+		attempts++
+		if !loggedIn {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		uploads = append(uploads, fields)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	endpoint, _ := url.Parse(server.URL)
+	c, err := New(endpoint, "user", "pass")
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	ctx := context.Background()
+
+	// ── map files: sorted, replayed after auto login ────────
+	if err = c.AddNewTorrents(ctx, map[string][]byte{
+		"c.torrent": []byte("C"),
+		"a.torrent": []byte("A"),
+		"b.torrent": []byte("B"),
+	}, nil, nil); err != nil {
+		t.Fatalf("AddNewTorrents: %v", err)
+	}
+	if attempts != 2 || len(uploads) != 1 {
+		t.Fatalf("expected 2 attempts and 1 upload, got %d and %d", attempts, len(uploads))
+	}
+	if got := strings.Join(uploads[0], ","); got != "a.torrent=A,b.torrent=B,c.torrent=C,urls=" {
+		t.Errorf("unexpected upload: %s", got)
+	}
+
+	// ── fs sources ──────────────────────────────────────────
+	fsys := fstest.MapFS{
+		"torrents/z.torrent":     {Data: []byte("Z")},
+		"torrents/sub/y.torrent": {Data: []byte("Y")},
+		"torrents/readme.txt":    {Data: []byte("not a torrent")},
+	}
+	sources, err := TorrentFilesFromFS(fsys, "torrents")
+	if err != nil {
+		t.Fatalf("TorrentFilesFromFS: %v", err)
+	}
+	magnet, _ := url.Parse("magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567")
+	sources = append(sources, TorrentFileFromReader("reader.torrent", bytes.NewReader([]byte("R"))))
+	if err = c.AddNewTorrentsFromSources(ctx, sources, []*url.URL{magnet}, &AddNewTorrentsOptions{
+		Category: String("linux"),
+	}); err != nil {
+		t.Fatalf("AddNewTorrentsFromSources: %v", err)
+	}
+	expected := "y.torrent=Y,z.torrent=Z,reader.torrent=R,urls=" + magnet.String() + ",category=linux"
+	if got := strings.Join(uploads[1], ","); got != expected {
+		t.Errorf("unexpected upload:\n%s\nexpected:\n%s", got, expected)
+	}
+
+	// ── unknown size: buffered ──────────────────────────────
+	if err = c.AddNewTorrentsFromSources(ctx, []TorrentFileSource{
+		TorrentFileFromReader("unsized.torrent", io.MultiReader(strings.NewReader("U"))),
+	}, nil, nil); err != nil {
+		t.Fatalf("AddNewTorrentsFromSources (unknown size): %v", err)
+	}
+	if got := strings.Join(uploads[2], ","); got != "unsized.torrent=U,urls=" {
+		t.Errorf("unexpected upload: %s", got)
+	}
+	if chunked != 0 {
+		t.Errorf("%d requests sent without Content-Length", chunked)
+	}
+
+	// ── errors ──────────────────────────────────────────────
+	if err = c.AddNewTorrentsFromSources(ctx, []TorrentFileSource{
+		TorrentFileFromReader("empty.torrent", bytes.NewReader(nil)),
+	}, nil, nil); err == nil || !strings.Contains(err.Error(), "is empty") {
+		t.Errorf("expected an empty file error, got %v", err)
+	}
+	if err = c.AddNewTorrentsFromSources(ctx, []TorrentFileSource{
+		TorrentFileFromPath(filepath.Join(t.TempDir(), "missing.torrent")),
+	}, nil, nil); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+	if err = c.AddNewTorrentsFromSources(ctx, []TorrentFileSource{
+		TorrentFileFromPath("file.txt"),
+	}, nil, nil); err == nil {
+		t.Error("expected an error for a non .torrent file")
+	}
+}
+
+func TestAddNewTorrentsOptionsVersions(t *testing.T) {
+	// ── fake instance with a settable API version ───────────
+	var (
+		access     sync.Mutex
+		apiVersion string
+		fields     map[string]string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/app/webapiVersion", func(w http.ResponseWriter, r *http.Request) {
+		access.Lock()
+		defer access.Unlock()
+		w.Header().Set(contentTypeHeader, contentTypeHeaderTextPlain)
+		_, _ = w.Write([]byte(apiVersion))
+	})
+	mux.HandleFunc("/api/v2/torrents/add", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		access.Lock()
+		defer access.Unlock()
+		fields = make(map[string]string, len(r.MultipartForm.Value))
+		for name, values := range r.MultipartForm.Value {
+			fields[name] = values[0]
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	endpoint, _ := url.Parse(server.URL)
+	magnet, _ := url.Parse("magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567")
+	add := func(version string, options *AddNewTorrentsOptions) map[string]string {
+		t.Helper()
+		access.Lock()
+		apiVersion = version
+		access.Unlock()
+		// new client as the API version is cached
+		c, err := New(endpoint, "user", "pass")
+		if err != nil {
+			t.Fatalf("creating client: %v", err)
+		}
+		if err = c.AddNewTorrents(context.Background(), nil, []*url.URL{magnet}, options); err != nil {
+			t.Fatalf("AddNewTorrents: %v", err)
+		}
+		access.Lock()
+		defer access.Unlock()
+		return fields
+	}
+
+	// ── v5 fields ───────────────────────────────────────────
+	inactive := 90 * time.Minute
+	got := add("2.11.3", &AddNewTorrentsOptions{
+		Stopped:                  Bool(true),
+		StopCondition:            TorrentStopConditionMetadataReceived.Ptr(),
+		ContentLayout:            TorrentContentLayoutNoSubfolder.Ptr(),
+		DownloadPath:             String("/incomplete"),
+		UseDownloadPath:          Bool(true),
+		InactiveSeedingTimeLimit: &inactive,
+		ShareLimitAction:         ShareLimitActionRemove.Ptr(),
+		AddToTopOfQueue:          Bool(true),
+		Forced:                   Bool(false),
+		Cookies:                  []*http.Cookie{{Name: "uid", Value: "42"}, {Name: "pass", Value: "secret"}},
+	})
+	for field, expected := range map[string]string{
+		"stopped":                  "true",
+		"stopCondition":            "MetadataReceived",
+		"contentLayout":            "NoSubfolder",
+		"downloadPath":             "/incomplete",
+		"useDownloadPath":          "true",
+		"inactiveSeedingTimeLimit": "90",
+		"shareLimitAction":         "Remove",
+		"addToTopOfQueue":          "true",
+		"forced":                   "false",
+		"cookie":                   "uid=42; pass=secret",
+	} {
+		if got[field] != expected {
+			t.Errorf("%s: expected %q, got %q", field, expected, got[field])
+		}
+	}
+	if _, found := got["paused"]; found {
+		t.Error("paused should not be sent to a v5 server")
+	}
+
+	// ── legacy names ────────────────────────────────────────
+	got = add("2.8.3", &AddNewTorrentsOptions{
+		Stopped:       Bool(true),
+		ContentLayout: TorrentContentLayoutNoSubfolder.Ptr(),
+	})
+	if got["paused"] != "true" || got["contentLayout"] != "NoSubfolder" {
+		t.Errorf("unexpected fields for API 2.8.3: %v", got)
+	}
+	if _, found := got["stopped"]; found {
+		t.Error("stopped should not be sent to a v4 server")
+	}
+	got = add("2.6.2", &AddNewTorrentsOptions{
+		ContentLayout: TorrentContentLayoutSubfolder.Ptr(),
+	})
+	if got["root_folder"] != "true" {
+		t.Errorf("unexpected fields for API 2.6.2: %v", got)
+	}
+
+	// ── deprecated fields ───────────────────────────────────
+	got = add("2.11.3", &AddNewTorrentsOptions{
+		Paused:     Bool(true),
+		RootFolder: Bool(false),
+	})
+	if got["stopped"] != "true" || got["contentLayout"] != "NoSubfolder" {
+		t.Errorf("unexpected fields for deprecated options: %v", got)
+	}
+}
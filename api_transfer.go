@@ -2,23 +2,93 @@ package qbtapi
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/hekmon/cunits/v3"
 )
 
 const transferAPIName = "transfer"
 
 // GlobalTransferInfo contains the global transfer information.
 type GlobalTransferInfo struct {
-	DlInfoSpeed      int    `json:"dl_info_speed"`     // Global download rate (bytes/s)
-	DlInfoData       int    `json:"dl_info_data"`      // Data downloaded this session (bytes)
-	UpInfoSpeed      int    `json:"up_info_speed"`     // Global upload rate (bytes/s)
-	UpInfoData       int    `json:"up_info_data"`      // Data uploaded this session (bytes)
-	DlRateLimit      int    `json:"dl_rate_limit"`     // Download rate limit (bytes/s)
-	UpRateLimit      int    `json:"up_rate_limit"`     // Upload rate limit (bytes/s)
-	DHTNodes         int    `json:"dht_nodes"`         // DHT nodes connected to
-	ConnectionStatus string `json:"connection_status"` // Connection status: connected, firewalled, disconnected
+	DlInfoSpeed      Speed       `json:"dl_info_speed"`     // Global download rate
+	DlInfoData       cunits.Bits `json:"dl_info_data"`      // Data downloaded this session
+	UpInfoSpeed      Speed       `json:"up_info_speed"`     // Global upload rate
+	UpInfoData       cunits.Bits `json:"up_info_data"`      // Data uploaded this session
+	DlRateLimit      Speed       `json:"dl_rate_limit"`     // Download rate limit. UnlimitedSpeedLimit if no limit is applied.
+	UpRateLimit      Speed       `json:"up_rate_limit"`     // Upload rate limit. UnlimitedSpeedLimit if no limit is applied.
+	DHTNodes         int         `json:"dht_nodes"`         // DHT nodes connected to
+	ConnectionStatus string      `json:"connection_status"` // Connection status: connected, firewalled, disconnected
+}
+
+func (gti *GlobalTransferInfo) UnmarshalJSON(data []byte) (err error) {
+	type mask GlobalTransferInfo
+	tmp := struct {
+		*mask
+		// Custom unmarshaling
+		DlInfoSpeed int   `json:"dl_info_speed"` // Global download rate (bytes/s)
+		DlInfoData  int64 `json:"dl_info_data"`  // Data downloaded this session (bytes)
+		UpInfoSpeed int   `json:"up_info_speed"` // Global upload rate (bytes/s)
+		UpInfoData  int64 `json:"up_info_data"`  // Data uploaded this session (bytes)
+		DlRateLimit int   `json:"dl_rate_limit"` // Download rate limit (bytes/s), 0 if no limit is applied
+		UpRateLimit int   `json:"up_rate_limit"` // Upload rate limit (bytes/s), 0 if no limit is applied
+	}{
+		mask: (*mask)(gti),
+	}
+	// Unmarshall to tmp struct
+	if err = json.Unmarshal(data, &tmp); err != nil {
+		return
+	}
+	// Adapt to golang types
+	gti.DlInfoSpeed = GetSpeedFromBytes(tmp.DlInfoSpeed)
+	gti.DlInfoData = cunits.ImportInBytes(float64(tmp.DlInfoData))
+	gti.UpInfoSpeed = GetSpeedFromBytes(tmp.UpInfoSpeed)
+	gti.UpInfoData = cunits.ImportInBytes(float64(tmp.UpInfoData))
+	gti.DlRateLimit = getSpeedLimitFromBytes(tmp.DlRateLimit)
+	gti.UpRateLimit = getSpeedLimitFromBytes(tmp.UpRateLimit)
+	return
+}
+
+func (gti GlobalTransferInfo) MarshalJSON() ([]byte, error) {
+	type mask GlobalTransferInfo
+	tmp := struct {
+		mask
+		// Custom marshaling
+		DlInfoSpeed int   `json:"dl_info_speed"` // Global download rate (bytes/s)
+		DlInfoData  int64 `json:"dl_info_data"`  // Data downloaded this session (bytes)
+		UpInfoSpeed int   `json:"up_info_speed"` // Global upload rate (bytes/s)
+		UpInfoData  int64 `json:"up_info_data"`  // Data uploaded this session (bytes)
+		DlRateLimit int   `json:"dl_rate_limit"` // Download rate limit (bytes/s), 0 if no limit is applied
+		UpRateLimit int   `json:"up_rate_limit"` // Upload rate limit (bytes/s), 0 if no limit is applied
+	}{
+		mask:        mask(gti),
+		DlInfoSpeed: gti.DlInfoSpeed.ToBytes(),
+		DlInfoData:  int64(gti.DlInfoData.Bytes()),
+		UpInfoSpeed: gti.UpInfoSpeed.ToBytes(),
+		UpInfoData:  int64(gti.UpInfoData.Bytes()),
+		DlRateLimit: speedLimitToBytes(gti.DlRateLimit),
+		UpRateLimit: speedLimitToBytes(gti.UpRateLimit),
+	}
+	return json.Marshal(tmp)
+}
+
+// getSpeedLimitFromBytes converts a global limit (bytes/s) where 0 (or less) means no limit.
+func getSpeedLimitFromBytes(bytes int) Speed {
+	if bytes <= 0 {
+		return UnlimitedSpeedLimit
+	}
+	return GetSpeedFromBytes(bytes)
+}
+
+// speedLimitToBytes converts a speed to a global limit (bytes/s) where 0 means no limit.
+func speedLimitToBytes(limit Speed) int {
+	if limit.Unlimited() {
+		return 0
+	}
+	return limit.ToBytes()
 }
 
 // GetGlobalTransferInfo returns info you usually see in qBt status bar.
@@ -73,10 +143,95 @@ func (c *Client) ToggleAlternativeSpeedLimits(ctx context.Context) (err error) {
 	return
 }
 
-// GetGlobalDownloadLimit returns the value of current global download speed limit in bytes/second.
-// This value will be zero if no limit is applied.
+// SetSpeedLimitsMode enables (alt = true) or disables (alt = false) the alternative speed limits.
+// Unlike ToggleAlternativeSpeedLimits, the resulting state does not depend on the current one.
+// https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#set-alternative-speed-limits-state
+func (c *Client) SetSpeedLimitsMode(ctx context.Context, alt bool) (err error) {
+	mode := "0"
+	if alt {
+		mode = "1"
+	}
+	req, err := c.requestBuild(ctx, "POST", transferAPIName, "setSpeedLimitsMode", map[string]string{
+		"mode": mode,
+	}, nil)
+	if err != nil {
+		err = fmt.Errorf("building request failed: %w", err)
+		return
+	}
+	if err = c.requestExecute(req, nil, true); err != nil {
+		err = fmt.Errorf("executing request failed: %w", err)
+	}
+	return
+}
+
+// AlternativeSpeedLimitsError is returned by SetAlternativeSpeedLimits when switching to the alternative limits
+// failed and the previous alternative limits could not be restored: the server is left with the new
+// alternative limits set but not in use.
+type AlternativeSpeedLimitsError struct {
+	Download    Speed // Alternative download limit applied on the server
+	Upload      Speed // Alternative upload limit applied on the server
+	SwitchErr   error // Why switching to the alternative limits failed
+	RollbackErr error // Why restoring the previous alternative limits failed
+}
+
+func (asle *AlternativeSpeedLimitsError) Error() string {
+	return fmt.Sprintf("switching to alternative limits failed: %s (restoring previous alternative limits failed too: %s): alternative limits left at download %s and upload %s without being in use",
+		asle.SwitchErr, asle.RollbackErr, asle.Download, asle.Upload)
+}
+
+// Unwrap allows errors.Is() and errors.As() to inspect both the switch and the rollback errors.
+func (asle *AlternativeSpeedLimitsError) Unwrap() []error {
+	return []error{asle.SwitchErr, asle.RollbackErr}
+}
+
+// SetAlternativeSpeedLimits sets the alternative global speed limits and switches to them.
+// Use UnlimitedSpeedLimit to remove a limit. The update is not atomic: the limits are set with one
+// preferences call then the mode is switched with another one. If switching the mode fails, the previous
+// alternative limits are restored on a best effort basis: if restoring them fails too, a
+// *AlternativeSpeedLimitsError reporting the limits left on the server is returned.
+func (c *Client) SetAlternativeSpeedLimits(ctx context.Context, download, upload Speed) (err error) {
+	previous, err := c.GetApplicationPreferences(ctx)
+	if err != nil {
+		err = fmt.Errorf("getting current alternative limits failed: %w", err)
+		return
+	}
+	if err = c.SetApplicationPreferences(ctx, ApplicationPreferences{
+		AltDlLimit: Int(speedLimitToPreference(download)),
+		AltUpLimit: Int(speedLimitToPreference(upload)),
+	}); err != nil {
+		err = fmt.Errorf("setting alternative limits failed: %w", err)
+		return
+	}
+	if err = c.SetSpeedLimitsMode(ctx, true); err != nil {
+		if rollbackErr := c.SetApplicationPreferences(ctx, ApplicationPreferences{
+			AltDlLimit: previous.AltDlLimit,
+			AltUpLimit: previous.AltUpLimit,
+		}); rollbackErr != nil {
+			err = &AlternativeSpeedLimitsError{
+				Download:    download,
+				Upload:      upload,
+				SwitchErr:   err,
+				RollbackErr: rollbackErr,
+			}
+			return
+		}
+		err = fmt.Errorf("switching to alternative limits failed: %w", err)
+	}
+	return
+}
+
+// speedLimitToPreference converts a speed to a preferences limit (bytes/s) where 0 means no limit.
+func speedLimitToPreference(limit Speed) int {
+	if limit.Unlimited() {
+		return 0
+	}
+	return int(limit.Bytes())
+}
+
+// GetGlobalDownloadLimit returns the value of current global download speed limit.
+// UnlimitedSpeedLimit is returned if no limit is applied.
 // https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#get-global-download-limit
-func (c *Client) GetGlobalDownloadLimit(ctx context.Context) (limit int, err error) {
+func (c *Client) GetGlobalDownloadLimit(ctx context.Context) (limit Speed, err error) {
 	req, err := c.requestBuild(ctx, "GET", transferAPIName, "downloadLimit", nil, nil)
 	if err != nil {
 		err = fmt.Errorf("building request failed: %w", err)
@@ -87,18 +242,20 @@ func (c *Client) GetGlobalDownloadLimit(ctx context.Context) (limit int, err err
 		err = fmt.Errorf("executing request failed: %w", err)
 		return
 	}
-	limit, err = strconv.Atoi(raw)
+	bytes, err := strconv.Atoi(raw)
 	if err != nil {
 		err = fmt.Errorf("parsing download limit failed: %w", err)
+		return
 	}
+	limit = getSpeedLimitFromBytes(bytes)
 	return
 }
 
-// SetGlobalDownloadLimit sets the global download speed limit in bytes/second.
+// SetGlobalDownloadLimit sets the global download speed limit. Use UnlimitedSpeedLimit to remove the limit.
 // https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#set-global-download-limit
-func (c *Client) SetGlobalDownloadLimit(ctx context.Context, limit int) (err error) {
+func (c *Client) SetGlobalDownloadLimit(ctx context.Context, limit Speed) (err error) {
 	req, err := c.requestBuild(ctx, "POST", transferAPIName, "setDownloadLimit", map[string]string{
-		"limit": strconv.Itoa(limit),
+		"limit": strconv.Itoa(speedLimitToBytes(limit)),
 	}, nil)
 	if err != nil {
 		err = fmt.Errorf("building request failed: %w", err)
@@ -110,10 +267,10 @@ func (c *Client) SetGlobalDownloadLimit(ctx context.Context, limit int) (err err
 	return
 }
 
-// GetGlobalUploadLimit returns the value of current global upload speed limit in bytes/second.
-// This value will be zero if no limit is applied.
+// GetGlobalUploadLimit returns the value of current global upload speed limit.
+// UnlimitedSpeedLimit is returned if no limit is applied.
 // https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#get-global-upload-limit
-func (c *Client) GetGlobalUploadLimit(ctx context.Context) (limit int, err error) {
+func (c *Client) GetGlobalUploadLimit(ctx context.Context) (limit Speed, err error) {
 	req, err := c.requestBuild(ctx, "GET", transferAPIName, "uploadLimit", nil, nil)
 	if err != nil {
 		err = fmt.Errorf("building request failed: %w", err)
@@ -124,18 +281,20 @@ func (c *Client) GetGlobalUploadLimit(ctx context.Context) (limit int, err error
 		err = fmt.Errorf("executing request failed: %w", err)
 		return
 	}
-	limit, err = strconv.Atoi(raw)
+	bytes, err := strconv.Atoi(raw)
 	if err != nil {
 		err = fmt.Errorf("parsing upload limit failed: %w", err)
+		return
 	}
+	limit = getSpeedLimitFromBytes(bytes)
 	return
 }
 
-// SetGlobalUploadLimit sets the global upload speed limit in bytes/second.
+// SetGlobalUploadLimit sets the global upload speed limit. Use UnlimitedSpeedLimit to remove the limit.
 // https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#set-global-upload-limit
-func (c *Client) SetGlobalUploadLimit(ctx context.Context, limit int) (err error) {
+func (c *Client) SetGlobalUploadLimit(ctx context.Context, limit Speed) (err error) {
 	req, err := c.requestBuild(ctx, "POST", transferAPIName, "setUploadLimit", map[string]string{
-		"limit": strconv.Itoa(limit),
+		"limit": strconv.Itoa(speedLimitToBytes(limit)),
 	}, nil)
 	if err != nil {
 		err = fmt.Errorf("building request failed: %w", err)
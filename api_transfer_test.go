@@ -2,6 +2,11 @@ package qbtapi
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
 	"testing"
 )
 
@@ -17,7 +22,7 @@ func TestTransferDomain(t *testing.T) {
 	if info.ConnectionStatus == "" {
 		t.Fatal("GetGlobalTransferInfo returned empty connection status")
 	}
-	t.Logf("transfer info: dl=%d bytes/s, ul=%d bytes/s, dht=%d, status=%s",
+	t.Logf("transfer info: dl=%s, ul=%s, dht=%d, status=%s",
 		info.DlInfoSpeed, info.UpInfoSpeed, info.DHTNodes, info.ConnectionStatus)
 
 	// ── alternative speed limits toggle round-trip ──────────
@@ -53,16 +58,57 @@ func TestTransferDomain(t *testing.T) {
 			originalAltState, altStateRestored)
 	}
 
+	// ── alternative speed limits mode ───────────────────────
+	if err := c.SetSpeedLimitsMode(ctx, !originalAltState); err != nil {
+		t.Fatalf("SetSpeedLimitsMode: %v", err)
+	}
+	if altState, err := c.GetAlternativeSpeedLimitsState(ctx); err != nil {
+		t.Fatalf("GetAlternativeSpeedLimitsState (after set mode): %v", err)
+	} else if altState == originalAltState {
+		t.Fatalf("alternative speed limits mode not applied: expected %v, got %v", !originalAltState, altState)
+	}
+
+	prefs, err := c.GetApplicationPreferences(ctx)
+	if err != nil {
+		t.Fatalf("GetApplicationPreferences: %v", err)
+	}
+	if err := c.SetAlternativeSpeedLimits(ctx, GetSpeedFromBytes(51200), UnlimitedSpeedLimit); err != nil {
+		t.Fatalf("SetAlternativeSpeedLimits: %v", err)
+	}
+	if altState, err := c.GetAlternativeSpeedLimitsState(ctx); err != nil {
+		t.Fatalf("GetAlternativeSpeedLimitsState (after alternative limits): %v", err)
+	} else if !altState {
+		t.Fatal("SetAlternativeSpeedLimits did not switch to alternative limits")
+	}
+	altPrefs, err := c.GetApplicationPreferences(ctx)
+	if err != nil {
+		t.Fatalf("GetApplicationPreferences (after alternative limits): %v", err)
+	}
+	if altPrefs.AltDlLimit == nil || *altPrefs.AltDlLimit != 51200 {
+		t.Fatalf("alternative download limit not applied: %v", altPrefs.AltDlLimit)
+	}
+
+	// restore
+	if err := c.SetApplicationPreferences(ctx, ApplicationPreferences{
+		AltDlLimit: prefs.AltDlLimit,
+		AltUpLimit: prefs.AltUpLimit,
+	}); err != nil {
+		t.Fatalf("SetApplicationPreferences (restore): %v", err)
+	}
+	if err := c.SetSpeedLimitsMode(ctx, originalAltState); err != nil {
+		t.Fatalf("SetSpeedLimitsMode (restore): %v", err)
+	}
+
 	// ── global download limit round-trip ────────────────────
 	originalDlLimit, err := c.GetGlobalDownloadLimit(ctx)
 	if err != nil {
 		t.Fatalf("GetGlobalDownloadLimit: %v", err)
 	}
-	t.Logf("global download limit original: %d bytes/s", originalDlLimit)
+	t.Logf("global download limit original: %s", originalDlLimit)
 
-	testDlLimit := 102400 // 100 KiB/s
+	testDlLimit := GetSpeedFromBytes(102400) // 100 KiB/s
 	if originalDlLimit == testDlLimit {
-		testDlLimit = 204800
+		testDlLimit = GetSpeedFromBytes(204800)
 	}
 
 	if err := c.SetGlobalDownloadLimit(ctx, testDlLimit); err != nil {
@@ -74,7 +120,7 @@ func TestTransferDomain(t *testing.T) {
 		t.Fatalf("GetGlobalDownloadLimit (after set): %v", err)
 	}
 	if dlLimitAfterSet != testDlLimit {
-		t.Fatalf("global download limit mismatch: expected %d, got %d",
+		t.Fatalf("global download limit mismatch: expected %s, got %s",
 			testDlLimit, dlLimitAfterSet)
 	}
 
@@ -87,7 +133,7 @@ func TestTransferDomain(t *testing.T) {
 		t.Fatalf("GetGlobalDownloadLimit (after restore): %v", err)
 	}
 	if dlLimitRestored != originalDlLimit {
-		t.Fatalf("global download limit not restored: expected %d, got %d",
+		t.Fatalf("global download limit not restored: expected %s, got %s",
 			originalDlLimit, dlLimitRestored)
 	}
 
@@ -96,11 +142,11 @@ func TestTransferDomain(t *testing.T) {
 	if err != nil {
 		t.Fatalf("GetGlobalUploadLimit: %v", err)
 	}
-	t.Logf("global upload limit original: %d bytes/s", originalUlLimit)
+	t.Logf("global upload limit original: %s", originalUlLimit)
 
-	testUlLimit := 102400 // 100 KiB/s
+	testUlLimit := GetSpeedFromBytes(102400) // 100 KiB/s
 	if originalUlLimit == testUlLimit {
-		testUlLimit = 204800
+		testUlLimit = GetSpeedFromBytes(204800)
 	}
 
 	if err := c.SetGlobalUploadLimit(ctx, testUlLimit); err != nil {
@@ -112,7 +158,7 @@ func TestTransferDomain(t *testing.T) {
 		t.Fatalf("GetGlobalUploadLimit (after set): %v", err)
 	}
 	if ulLimitAfterSet != testUlLimit {
-		t.Fatalf("global upload limit mismatch: expected %d, got %d",
+		t.Fatalf("global upload limit mismatch: expected %s, got %s",
 			testUlLimit, ulLimitAfterSet)
 	}
 
@@ -125,7 +171,7 @@ func TestTransferDomain(t *testing.T) {
 		t.Fatalf("GetGlobalUploadLimit (after restore): %v", err)
 	}
 	if ulLimitRestored != originalUlLimit {
-		t.Fatalf("global upload limit not restored: expected %d, got %d",
+		t.Fatalf("global upload limit not restored: expected %s, got %s",
 			originalUlLimit, ulLimitRestored)
 	}
 
@@ -135,3 +181,48 @@ func TestTransferDomain(t *testing.T) {
 		t.Fatalf("BanPeers: %v", err)
 	}
 }
+
+func TestSetAlternativeSpeedLimitsRollbackFailure(t *testing.T) {
+	var (
+		access sync.Mutex
+		sets   int
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/app/preferences", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		_, _ = w.Write([]byte(`{"alt_dl_limit":10240,"alt_up_limit":0}`))
+	})
+	mux.HandleFunc("/api/v2/app/setPreferences", func(w http.ResponseWriter, r *http.Request) {
+		access.Lock()
+		defer access.Unlock()
+		if sets++; sets > 1 {
+			// the rollback fails
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/api/v2/transfer/setSpeedLimitsMode", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("parsing test server URL: %v", err)
+	}
+	client, err := New(endpoint, "user", "pass")
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	err = client.SetAlternativeSpeedLimits(context.Background(), GetSpeedFromBytes(51200), UnlimitedSpeedLimit)
+	var limitsErr *AlternativeSpeedLimitsError
+	if !errors.As(err, &limitsErr) {
+		t.Fatalf("expected a *AlternativeSpeedLimitsError, got %v", err)
+	}
+	if limitsErr.Download != GetSpeedFromBytes(51200) || !limitsErr.Upload.Unlimited() {
+		t.Errorf("unexpected applied limits: download %s, upload %s", limitsErr.Download, limitsErr.Upload)
+	}
+	var httpErr HTTPError
+	if !errors.As(err, &httpErr) || httpErr != http.StatusInternalServerError {
+		t.Errorf("expected the HTTP error to be reachable, got %v", err)
+	}
+}
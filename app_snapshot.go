@@ -0,0 +1,193 @@
+package qbtapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+	Application preferences snapshots
+*/
+
+const (
+	preferencesSnapshotPrefix     = "preferences-"
+	preferencesSnapshotExtension  = ".json"
+	preferencesSnapshotTimeLayout = "20060102T150405.000000000Z"
+)
+
+// PreferencesMachineSpecificKeys lists the preferences (by their JSON key) that are tied to the
+// machine or the WebUI access itself. They are skipped by RestoreApplicationPreferences unless
+// explicitly opted in, as restoring them on another host (or after a network change) can lock
+// you out of the WebUI or break connectivity.
+var PreferencesMachineSpecificKeys = []string{
+	"current_network_interface",
+	"current_interface_address",
+	"listen_port",
+	"random_port",
+	"announce_ip",
+	"web_ui_address",
+	"web_ui_port",
+	"web_ui_username",
+	"web_ui_password",
+	"web_ui_https_key_path",
+	"web_ui_https_cert_path",
+	"bypass_auth_subnet_whitelist",
+	"bypass_auth_subnet_whitelist_enabled",
+	"bypass_local_auth",
+}
+
+// PreferencesSnapshot is a timestamped copy of the application preferences.
+type PreferencesSnapshot struct {
+	Time        time.Time              `json:"time"`
+	Preferences ApplicationPreferences `json:"preferences"`
+}
+
+// SnapshotApplicationPreferences retrieves the current application preferences and timestamps them.
+func (c *Client) SnapshotApplicationPreferences(ctx context.Context) (snapshot PreferencesSnapshot, err error) {
+	if snapshot.Preferences, err = c.GetApplicationPreferences(ctx); err != nil {
+		err = fmt.Errorf("getting application preferences failed: %w", err)
+		return
+	}
+	snapshot.Time = time.Now().UTC()
+	return
+}
+
+// SavePreferencesSnapshot writes the snapshot as an indented JSON file within dir.
+// The file name is derived from the snapshot time so snapshots sort chronologically.
+func SavePreferencesSnapshot(dir string, snapshot PreferencesSnapshot) (path string, err error) {
+	data, err := json.MarshalIndent(snapshot, "", "\t")
+	if err != nil {
+		err = fmt.Errorf("marshaling snapshot failed: %w", err)
+		return
+	}
+	path = filepath.Join(dir, preferencesSnapshotPrefix+
+		snapshot.Time.UTC().Format(preferencesSnapshotTimeLayout)+preferencesSnapshotExtension)
+	if err = os.WriteFile(path, data, 0600); err != nil {
+		err = fmt.Errorf("writing snapshot file %q failed: %w", path, err)
+	}
+	return
+}
+
+// LoadPreferencesSnapshot reads a snapshot previously written by SavePreferencesSnapshot.
+func LoadPreferencesSnapshot(path string) (snapshot PreferencesSnapshot, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		err = fmt.Errorf("reading snapshot file %q failed: %w", path, err)
+		return
+	}
+	if err = json.Unmarshal(data, &snapshot); err != nil {
+		err = fmt.Errorf("unmarshaling snapshot file %q failed: %w", path, err)
+	}
+	return
+}
+
+// ListPreferencesSnapshots returns the paths of all the snapshots found within dir, oldest first.
+func ListPreferencesSnapshots(dir string) (paths []string, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		err = fmt.Errorf("reading snapshots directory %q failed: %w", dir, err)
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() ||
+			!strings.HasPrefix(entry.Name(), preferencesSnapshotPrefix) ||
+			!strings.HasSuffix(entry.Name(), preferencesSnapshotExtension) {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, entry.Name()))
+	}
+	// entries are already sorted by name, and names embed the snapshot time
+	return
+}
+
+// PreferenceChange represents a single preference which value differs between two preferences sets.
+// Key is the preference JSON key. A nil Old or New value means the preference was not set on that side.
+type PreferenceChange struct {
+	Key string
+	Old any
+	New any
+}
+
+func (pc PreferenceChange) String() string {
+	return fmt.Sprintf("%s: %v -> %v", pc.Key, pc.Old, pc.New)
+}
+
+// DiffApplicationPreferences returns the preferences which differ from before to after, sorted by key.
+func DiffApplicationPreferences(before, after ApplicationPreferences) (changes []PreferenceChange) {
+	beforeMap := before.getMap()
+	afterMap := after.getMap()
+	keys := make(map[string]struct{}, len(beforeMap))
+	for key := range beforeMap {
+		keys[key] = struct{}{}
+	}
+	for key := range afterMap {
+		keys[key] = struct{}{}
+	}
+	for key := range keys {
+		oldValue, newValue := beforeMap[key], afterMap[key]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		changes = append(changes, PreferenceChange{
+			Key: key,
+			Old: oldValue,
+			New: newValue,
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return
+}
+
+// RestoreApplicationPreferences restores the preferences of a snapshot on the remote server.
+// Only the preferences differing from the current server state are sent. Preferences listed in
+// PreferencesMachineSpecificKeys are skipped unless their JSON key is present in includeKeys.
+// The changes actually sent to the server are returned.
+func (c *Client) RestoreApplicationPreferences(ctx context.Context, snapshot PreferencesSnapshot, includeKeys ...string) (applied []PreferenceChange, err error) {
+	current, err := c.GetApplicationPreferences(ctx)
+	if err != nil {
+		err = fmt.Errorf("getting current application preferences failed: %w", err)
+		return
+	}
+	// compute what needs to be restored
+	payload := make(map[string]any)
+	for _, change := range DiffApplicationPreferences(current, snapshot.Preferences) {
+		if change.New == nil {
+			// not part of the snapshot, nothing to restore
+			continue
+		}
+		if slices.Contains(PreferencesMachineSpecificKeys, change.Key) && !slices.Contains(includeKeys, change.Key) {
+			continue
+		}
+		payload[change.Key] = change.New
+		applied = append(applied, change)
+	}
+	if len(applied) == 0 {
+		return
+	}
+	// convert back to a targeted preferences update
+	data, err := json.Marshal(payload)
+	if err != nil {
+		err = fmt.Errorf("marshaling preferences to restore failed: %w", err)
+		return
+	}
+	var prefs ApplicationPreferences
+	if err = json.Unmarshal(data, &prefs); err != nil {
+		err = fmt.Errorf("unmarshaling preferences to restore failed: %w", err)
+		return
+	}
+	if err = c.SetApplicationPreferences(ctx, prefs); err != nil {
+		err = fmt.Errorf("setting application preferences failed: %w", err)
+		applied = nil
+	}
+	return
+}
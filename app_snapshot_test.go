@@ -0,0 +1,113 @@
+package qbtapi
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPreferencesSnapshotFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	// ── save & list ─────────────────────────────────────────
+	first := PreferencesSnapshot{
+		Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Preferences: ApplicationPreferences{
+			ListenPort: Int(6881),
+			DHT:        Bool(true),
+			ProxyType:  ProxyHTTPNoAuth.Ptr(),
+		},
+	}
+	second := PreferencesSnapshot{
+		Time: first.Time.Add(time.Hour),
+		Preferences: ApplicationPreferences{
+			ListenPort: Int(6882),
+			DHT:        Bool(true),
+			PeX:        Bool(false),
+			ProxyType:  ProxyHTTPNoAuth.Ptr(),
+		},
+	}
+	// save the newest first to check ordering does not depend on write order
+	if _, err := SavePreferencesSnapshot(dir, second); err != nil {
+		t.Fatalf("SavePreferencesSnapshot (second): %v", err)
+	}
+	firstPath, err := SavePreferencesSnapshot(dir, first)
+	if err != nil {
+		t.Fatalf("SavePreferencesSnapshot (first): %v", err)
+	}
+	paths, err := ListPreferencesSnapshots(dir)
+	if err != nil {
+		t.Fatalf("ListPreferencesSnapshots: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 snapshots, got %d", len(paths))
+	}
+	if paths[0] != firstPath {
+		t.Fatalf("expected oldest snapshot first (%s), got %s", firstPath, paths[0])
+	}
+
+	// ── load ────────────────────────────────────────────────
+	loaded, err := LoadPreferencesSnapshot(firstPath)
+	if err != nil {
+		t.Fatalf("LoadPreferencesSnapshot: %v", err)
+	}
+	if !loaded.Time.Equal(first.Time) {
+		t.Fatalf("snapshot time mismatch: expected %v, got %v", first.Time, loaded.Time)
+	}
+	if changes := DiffApplicationPreferences(first.Preferences, loaded.Preferences); len(changes) != 0 {
+		t.Fatalf("loaded snapshot differs from saved one: %v", changes)
+	}
+
+	// ── diff ────────────────────────────────────────────────
+	changes := DiffApplicationPreferences(first.Preferences, second.Preferences)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d: %v", len(changes), changes)
+	}
+	if changes[0].Key != "listen_port" || changes[1].Key != "pex" {
+		t.Fatalf("unexpected changed keys: %v", changes)
+	}
+	if changes[1].Old != nil {
+		t.Fatalf("expected pex to be unset in the old preferences, got %v", changes[1].Old)
+	}
+}
+
+func TestPreferencesSnapshotRestore(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	snapshot, err := c.SnapshotApplicationPreferences(ctx)
+	if err != nil {
+		t.Skipf("SnapshotApplicationPreferences failed — server may report a different API version than the library target (%s): %v", APIReferenceVersion, err)
+	}
+	if snapshot.Preferences.WebUISessionTimeout == nil {
+		t.Fatal("WebUISessionTimeout is nil in snapshot")
+	}
+	original := *snapshot.Preferences.WebUISessionTimeout
+
+	// change a preference, then restore the snapshot
+	if err := c.SetApplicationPreferences(ctx, ApplicationPreferences{
+		WebUISessionTimeout: Int(original + 1),
+	}); err != nil {
+		t.Fatalf("SetApplicationPreferences: %v", err)
+	}
+	applied, err := c.RestoreApplicationPreferences(ctx, snapshot)
+	if err != nil {
+		t.Fatalf("RestoreApplicationPreferences: %v", err)
+	}
+	t.Logf("restored preferences: %v", applied)
+	for _, change := range applied {
+		for _, key := range PreferencesMachineSpecificKeys {
+			if change.Key == key {
+				t.Fatalf("machine specific preference %q restored without opt-in", key)
+			}
+		}
+	}
+
+	after, err := c.GetApplicationPreferences(ctx)
+	if err != nil {
+		t.Fatalf("GetApplicationPreferences (after restore): %v", err)
+	}
+	if after.WebUISessionTimeout == nil || *after.WebUISessionTimeout != original {
+		t.Fatalf("WebUISessionTimeout not restored: expected %d, got %v", original, after.WebUISessionTimeout)
+	}
+}
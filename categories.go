@@ -0,0 +1,173 @@
+package qbtapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+	Categories tree
+*/
+
+// CategorySeparator separates the levels of nested category names ("parent/child").
+const CategorySeparator = "/"
+
+// CategoryNode is a level of the categories tree. See NewCategoryTree().
+type CategoryNode struct {
+	Name     string          // Last level of the category name, empty for the root node
+	Path     string          // Full category name, empty for the root node
+	Category *Category       // Category of the node, nil for the root node and for parents not existing as categories
+	Children []*CategoryNode // Subcategories, sorted by name
+}
+
+// NewCategoryTree builds the tree of nested categories as returned by GetAllCategories().
+// Parents of subcategories are part of the tree even if they do not exist as categories.
+func NewCategoryTree(categories map[string]Category) (root *CategoryNode) {
+	root = &CategoryNode{}
+	for _, name := range sortedKeys(categories) {
+		node := root
+		for _, level := range strings.Split(name, CategorySeparator) {
+			node = node.child(level)
+		}
+		category := categories[name]
+		node.Category = &category
+	}
+	root.Walk(func(node *CategoryNode) {
+		sort.Slice(node.Children, func(i, j int) bool { return node.Children[i].Name < node.Children[j].Name })
+	})
+	return
+}
+
+// child returns the subcategory name, creating it if needed.
+func (cn *CategoryNode) child(name string) *CategoryNode {
+	for _, child := range cn.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	child := &CategoryNode{
+		Name: name,
+		Path: name,
+	}
+	if cn.Path != "" {
+		child.Path = cn.Path + CategorySeparator + name
+	}
+	cn.Children = append(cn.Children, child)
+	return child
+}
+
+// Walk calls fn for the node and all its descendants, depth first, in children order.
+func (cn *CategoryNode) Walk(fn func(node *CategoryNode)) {
+	fn(cn)
+	for _, child := range cn.Children {
+		child.Walk(fn)
+	}
+}
+
+// Node returns the node of the category path (relative to cn) or nil if it does not exist.
+func (cn *CategoryNode) Node(path string) *CategoryNode {
+	if path == "" {
+		return cn
+	}
+	current := cn
+	for _, name := range strings.Split(path, CategorySeparator) {
+		var next *CategoryNode
+		for _, child := range current.Children {
+			if child.Name == name {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+// Categories returns the names of the existing categories of the node and its descendants, parents first.
+func (cn *CategoryNode) Categories() (names []string) {
+	cn.Walk(func(node *CategoryNode) {
+		if node.Category != nil {
+			names = append(names, node.Path)
+		}
+	})
+	return
+}
+
+// RenameCategory renames (or moves within the tree) the oldName category and all its subcategories to newName:
+// the new categories are created with the same settings, the torrents are moved to them with SetTorrentCategory()
+// and the old categories are removed. The old category names are returned mapped to the new ones.
+// Torrents managed automatically are relocated by qBittorrent if their new category save path differs, which
+// is the case for categories without save path as their location derives from the category name.
+func (c *Client) RenameCategory(ctx context.Context, oldName, newName string) (renamed map[string]string, err error) {
+	oldName, newName = strings.Trim(oldName, CategorySeparator), strings.Trim(newName, CategorySeparator)
+	if oldName == "" || newName == "" {
+		err = errors.New("category names can not be empty")
+		return
+	}
+	if newName == oldName || strings.HasPrefix(newName, oldName+CategorySeparator) {
+		err = fmt.Errorf("can not move category %q to %q: new name is within the category", oldName, newName)
+		return
+	}
+	categories, err := c.GetAllCategories(ctx)
+	if err != nil {
+		err = fmt.Errorf("getting categories failed: %w", err)
+		return
+	}
+	tree := NewCategoryTree(categories)
+	node := tree.Node(oldName)
+	if node == nil {
+		err = fmt.Errorf("category %q does not exist", oldName)
+		return
+	}
+	// plan
+	oldNames := node.Categories()
+	renamed = make(map[string]string, len(oldNames))
+	for _, name := range oldNames {
+		target := newName + strings.TrimPrefix(name, oldName)
+		if _, exists := categories[target]; exists {
+			err = fmt.Errorf("can not rename category %q to %q: category already exists", name, target)
+			return
+		}
+		renamed[name] = target
+	}
+	// create new categories (parents first), then move torrents
+	for _, name := range oldNames {
+		category := categories[name]
+		if err = c.CreateCategory(ctx, renamed[name], category.SavePath, category.DownloadPath); err != nil {
+			err = fmt.Errorf("creating category %q failed: %w", renamed[name], err)
+			return
+		}
+	}
+	for _, name := range oldNames {
+		var torrents []TorrentInfos
+		if torrents, err = c.GetTorrentList(ctx, &ListFilters{Category: String(name)}); err != nil {
+			err = fmt.Errorf("listing torrents of category %q failed: %w", name, err)
+			return
+		}
+		// with subcategories enabled, the filter also matches the torrents of the subcategories
+		var hashes []string
+		for _, torrent := range torrents {
+			if torrent.Category == name {
+				hashes = append(hashes, torrent.Hash)
+			}
+		}
+		if len(hashes) == 0 {
+			continue
+		}
+		if err = c.SetTorrentCategory(ctx, hashes, renamed[name]); err != nil {
+			err = fmt.Errorf("moving torrents of category %q failed: %w", name, err)
+			return
+		}
+	}
+	// remove old categories
+	if err = c.RemoveCategories(ctx, oldNames); err != nil {
+		err = fmt.Errorf("removing old categories failed: %w", err)
+	}
+	return
+}
@@ -0,0 +1,196 @@
+package qbtapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testCategoriesJSON = `{
+	"movies": {"name": "movies", "savePath": "/data/movies", "download_path": null},
+	"movies/4k": {"name": "movies/4k", "savePath": "", "download_path": "/incomplete/4k"},
+	"movies/4k/hdr": {"name": "movies/4k/hdr", "savePath": "/data/hdr", "download_path": false},
+	"tv/anime": {"name": "tv/anime", "savePath": "/data/anime"},
+	"music": {"name": "music", "savePath": "/data/music", "download_path": true}
+}`
+
+func TestCategoryModel(t *testing.T) {
+	var categories map[string]Category
+	if err := json.Unmarshal([]byte(testCategoriesJSON), &categories); err != nil {
+		t.Fatalf("unmarshaling categories: %v", err)
+	}
+	for name, expected := range map[string]*CategoryDownloadPath{
+		"movies":        nil,
+		"movies/4k":     {Enabled: true, Path: "/incomplete/4k"},
+		"movies/4k/hdr": {Enabled: false},
+		"tv/anime":      nil,
+		"music":         {Enabled: true},
+	} {
+		if got := categories[name].DownloadPath; !reflect.DeepEqual(got, expected) {
+			t.Errorf("category %s: expected download path %+v, got %+v", name, expected, got)
+		}
+	}
+	for _, test := range []struct {
+		downloadPath CategoryDownloadPath
+		expected     string
+	}{
+		{CategoryDownloadPath{Enabled: false, Path: "/ignored"}, `false`},
+		{CategoryDownloadPath{Enabled: true, Path: "/incomplete"}, `"/incomplete"`},
+	} {
+		if data, err := json.Marshal(test.downloadPath); err != nil || string(data) != test.expected {
+			t.Errorf("marshaling %+v: expected %s, got %s (%v)", test.downloadPath, test.expected, data, err)
+		}
+	}
+
+	// ── tree ──────────────────────────────────────
+	tree := NewCategoryTree(categories)
+	var paths []string
+	tree.Walk(func(node *CategoryNode) {
+		paths = append(paths, node.Path)
+	})
+	if expected := []string{"", "movies", "movies/4k", "movies/4k/hdr", "music", "tv", "tv/anime"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("tree: expected %v, got %v", expected, paths)
+	}
+	if node := tree.Node("tv"); node == nil || node.Category != nil || len(node.Children) != 1 {
+		t.Errorf("tree: implicit parent: got %+v", node)
+	}
+	if node := tree.Node("movies/4k"); node == nil || node.Name != "4k" || node.Category == nil || node.Category.Name != "movies/4k" {
+		t.Errorf("tree: subcategory: got %+v", node)
+	}
+	if node := tree.Node("movies/8k"); node != nil {
+		t.Errorf("tree: expected no node, got %+v", node)
+	}
+	if names := tree.Node("movies").Categories(); !reflect.DeepEqual(names, []string{"movies", "movies/4k", "movies/4k/hdr"}) {
+		t.Errorf("tree: unexpected categories %v", names)
+	}
+}
+
+func TestRenameCategory(t *testing.T) {
+	ctx := context.Background()
+
+	// ── fake instance ──────────────────────────────────────
+	var (
+		access   sync.Mutex
+		created  []string
+		moved    = make(map[string]string) // hash -> category
+		removed  []string
+		torrents = map[string]string{ // hash -> category
+			"aaaa": "movies",
+			"bbbb": "movies/4k/hdr",
+			"cccc": "movies/4k/hdr",
+			"dddd": "music",
+		}
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/torrents/categories", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		_, _ = w.Write([]byte(testCategoriesJSON))
+	})
+	mux.HandleFunc("/api/v2/torrents/info", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		// as qBittorrent with subcategories enabled: the filter matches the category and its subcategories
+		filter := r.URL.Query().Get("category")
+		matching := []map[string]string{}
+		for _, hash := range sortedKeys(torrents) {
+			if category := torrents[hash]; category == filter || strings.HasPrefix(category, filter+CategorySeparator) {
+				matching = append(matching, map[string]string{"hash": hash, "category": category})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(matching)
+	})
+	mux.HandleFunc("/api/v2/torrents/createCategory", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		access.Lock()
+		defer access.Unlock()
+		created = append(created, strings.Join([]string{
+			r.PostForm.Get("category"),
+			r.PostForm.Get("savePath"),
+			r.PostForm.Get("downloadPathEnabled"),
+			r.PostForm.Get("downloadPath"),
+		}, "|"))
+	})
+	mux.HandleFunc("/api/v2/torrents/setCategory", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		access.Lock()
+		defer access.Unlock()
+		for _, hash := range strings.Split(r.PostForm.Get("hashes"), "|") {
+			if previous, found := moved[hash]; found {
+				t.Errorf("setCategory: torrent %s moved to %q then %q", hash, previous, r.PostForm.Get("category"))
+			}
+			moved[hash] = r.PostForm.Get("category")
+		}
+	})
+	mux.HandleFunc("/api/v2/torrents/removeCategories", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		access.Lock()
+		defer access.Unlock()
+		removed = strings.Split(r.PostForm.Get("categories"), "\n")
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("parsing test server URL: %v", err)
+	}
+	client, err := New(endpoint, "user", "pass")
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+
+	// ── invalid renames ──────────────────────────────────────
+	for _, names := range [][2]string{{"movies", "movies/old"}, {"movies", "tv/anime"}, {"books", "reading"}, {"", "x"}} {
+		if _, err = client.RenameCategory(ctx, names[0], names[1]); err == nil {
+			t.Errorf("RenameCategory(%q, %q): expected an error", names[0], names[1])
+		}
+	}
+	if len(created) != 0 || len(moved) != 0 || len(removed) != 0 {
+		t.Fatalf("RenameCategory: invalid renames modified categories: %v %v %v", created, moved, removed)
+	}
+
+	// ── recursive rename ──────────────────────────────────────
+	renamed, err := client.RenameCategory(ctx, "movies", "video/films")
+	if err != nil {
+		t.Fatalf("RenameCategory: %v", err)
+	}
+	if expected := map[string]string{
+		"movies":        "video/films",
+		"movies/4k":     "video/films/4k",
+		"movies/4k/hdr": "video/films/4k/hdr",
+	}; !reflect.DeepEqual(renamed, expected) {
+		t.Errorf("RenameCategory: expected %v, got %v", expected, renamed)
+	}
+	if expected := []string{
+		"video/films|/data/movies||",
+		"video/films/4k||true|/incomplete/4k",
+		"video/films/4k/hdr|/data/hdr|false|",
+	}; !reflect.DeepEqual(created, expected) {
+		t.Errorf("RenameCategory: expected created categories %v, got %v", expected, created)
+	}
+	if expected := map[string]string{
+		"aaaa": "video/films",
+		"bbbb": "video/films/4k/hdr",
+		"cccc": "video/films/4k/hdr",
+	}; !reflect.DeepEqual(moved, expected) {
+		t.Errorf("RenameCategory: expected moved torrents %v, got %v", expected, moved)
+	}
+	sort.Strings(removed)
+	if expected := []string{"movies", "movies/4k", "movies/4k/hdr"}; !reflect.DeepEqual(removed, expected) {
+		t.Errorf("RenameCategory: expected removed categories %v, got %v", expected, removed)
+	}
+}
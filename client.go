@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"sync"
 
 	"github.com/hashicorp/go-cleanhttp"
 	"golang.org/x/net/publicsuffix"
@@ -72,6 +73,14 @@ func WithUserAgent(userAgent string) ClientOption {
 	}
 }
 
+// WithAPIVersion sets the API version of the server instead of fetching it on first use (see ServerAPIVersion()).
+func WithAPIVersion(version APIVersion) ClientOption {
+	return func(c *Client) {
+		c.apiVersion = &version
+		c.apiVersionPinned = true
+	}
+}
+
 // Client is a statefull object allowing to interface the qBittorrent Web API on a particular endpoint.
 // Must be instanciated with New().
 type Client struct {
@@ -80,4 +89,8 @@ type Client struct {
 	url       *url.URL
 	client    *http.Client
 	userAgent string
+	// API version cache
+	apiVersionAccess sync.Mutex
+	apiVersion       *APIVersion
+	apiVersionPinned bool
 }
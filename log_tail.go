@@ -0,0 +1,159 @@
+package qbtapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+/*
+	Log followers
+*/
+
+// DefaultLogTailPollInterval is the interval between two log fetches of TailLog() and TailPeerLog() if not specified
+const DefaultLogTailPollInterval = 2 * time.Second
+
+// ErrLogRestarted is sent by the log followers when the server log has been reset (log IDs going backwards,
+// usually because qBittorrent restarted). The follower then resyncs by streaming the whole new log.
+var ErrLogRestarted = errors.New("server log has been reset")
+
+func (le LogEntry) logID() int                   { return le.ID }
+func (le LogEntry) logTimestamp() time.Time      { return le.Timestamp }
+func (ple PeerLogEntry) logID() int              { return ple.ID }
+func (ple PeerLogEntry) logTimestamp() time.Time { return ple.Timestamp }
+
+type tailableLogEntry interface {
+	LogEntry | PeerLogEntry
+	logID() int
+	logTimestamp() time.Time
+}
+
+// TailLog follows the main log: entries are streamed over the entries channel as they are logged, polling
+// every pollInterval (DefaultLogTailPollInterval if 0). filters can be nil; its LastKnownID is the starting
+// point (the whole log is streamed first if nil), its type filters apply to every poll.
+// Polling errors are sent over the errs channel and polling continues; ErrLogRestarted is sent when
+// a server restart is detected. Both channels must be drained and are closed once ctx is done.
+func (c *Client) TailLog(ctx context.Context, filters *LogFilters, pollInterval time.Duration) (entries <-chan LogEntry, errs <-chan error) {
+	var pollFilters LogFilters
+	if filters != nil {
+		pollFilters = *filters
+	}
+	lastKnownID := -1
+	if pollFilters.LastKnownID != nil {
+		lastKnownID = *pollFilters.LastKnownID
+	}
+	return tailLog(ctx, pollInterval, lastKnownID, func(ctx context.Context, lastKnownID int) ([]LogEntry, error) {
+		pollFilters.LastKnownID = &lastKnownID
+		return c.GetLog(ctx, &pollFilters)
+	})
+}
+
+// TailPeerLog follows the peer log (banned peers): see TailLog(), filters (which can be nil) only
+// holding the starting point.
+func (c *Client) TailPeerLog(ctx context.Context, filters *PeerLogFilters, pollInterval time.Duration) (entries <-chan PeerLogEntry, errs <-chan error) {
+	lastKnownID := -1
+	if filters != nil && filters.LastKnownID != nil {
+		lastKnownID = *filters.LastKnownID
+	}
+	return tailLog(ctx, pollInterval, lastKnownID, func(ctx context.Context, lastKnownID int) ([]PeerLogEntry, error) {
+		return c.GetPeerLog(ctx, &PeerLogFilters{LastKnownID: &lastKnownID})
+	})
+}
+
+func tailLog[E tailableLogEntry](ctx context.Context, pollInterval time.Duration, lastKnownID int,
+	fetch func(ctx context.Context, lastKnownID int) ([]E, error)) (<-chan E, <-chan error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultLogTailPollInterval
+	}
+	entries := make(chan E)
+	errs := make(chan error)
+	go func() {
+		defer close(entries)
+		defer close(errs)
+		sendErr := func(err error) bool {
+			select {
+			case errs <- err:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		follower := logFollower[E]{
+			lastID: lastKnownID,
+			fetch:  fetch,
+		}
+		pollTimer := time.NewTimer(0)
+		defer pollTimer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-pollTimer.C:
+			}
+			newEntries, restarted, err := follower.poll(ctx)
+			if restarted && !sendErr(ErrLogRestarted) {
+				return
+			}
+			if err != nil {
+				if ctx.Err() != nil || !sendErr(err) {
+					return
+				}
+			}
+			for _, entry := range newEntries {
+				select {
+				case entries <- entry:
+				case <-ctx.Done():
+					return
+				}
+			}
+			pollTimer.Reset(pollInterval)
+		}
+	}()
+	return entries, errs
+}
+
+// logFollower keeps track of the last entry seen to fetch the new ones and detect log resets.
+type logFollower[E tailableLogEntry] struct {
+	lastID    int
+	lastEntry *E // nil until an entry has been seen
+	fetch     func(ctx context.Context, lastKnownID int) ([]E, error)
+}
+
+// poll returns the entries logged since the last call. The last seen entry is fetched again: if it is gone
+// (or has changed) and older IDs are returned instead, the log has been reset and is fetched entirely.
+// If it is gone but only newer IDs are returned, it has been pruned (or the reset went unnoticed
+// for long enough for the new IDs to catch up, in which case the entries in between are lost).
+func (lf *logFollower[E]) poll(ctx context.Context) (entries []E, restarted bool, err error) {
+	if lf.lastEntry == nil {
+		if entries, err = lf.fetch(ctx, lf.lastID); err != nil {
+			err = fmt.Errorf("fetching log failed: %w", err)
+			return
+		}
+	} else {
+		if entries, err = lf.fetch(ctx, lf.lastID-1); err != nil {
+			err = fmt.Errorf("fetching log failed: %w", err)
+			return
+		}
+		switch {
+		case len(entries) > 0 && entries[0].logID() == lf.lastID &&
+			entries[0].logTimestamp().Equal((*lf.lastEntry).logTimestamp()):
+			entries = entries[1:]
+		case len(entries) > 0 && entries[0].logID() > lf.lastID:
+			// pruned
+		default:
+			restarted = true
+			if entries, err = lf.fetch(ctx, -1); err != nil {
+				err = fmt.Errorf("fetching log after reset failed: %w", err)
+				// fetch it entirely on next poll
+				lf.lastID, lf.lastEntry = -1, nil
+				return
+			}
+		}
+	}
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		lf.lastID, lf.lastEntry = last.logID(), &last
+	}
+	return
+}
@@ -0,0 +1,166 @@
+package qbtapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTailLog(t *testing.T) {
+	// ── fake instance ───────────────────────────────────────
+	var (
+		access sync.Mutex
+		log    []LogEntry
+		peers  []PeerLogEntry
+	)
+	appendLog := func(base time.Time, messages ...string) {
+		access.Lock()
+		defer access.Unlock()
+		for _, message := range messages {
+			log = append(log, LogEntry{
+				ID:        len(log),
+				Message:   message,
+				Timestamp: base.Add(time.Duration(len(log)) * time.Second),
+				Type:      LogMessageTypeInfo,
+			})
+		}
+	}
+	since := func(r *http.Request) int {
+		lastKnownID, err := strconv.Atoi(r.URL.Query().Get("last_known_id"))
+		if err != nil {
+			return -1
+		}
+		return lastKnownID
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/log/main", func(w http.ResponseWriter, r *http.Request) {
+		lastKnownID := since(r)
+		access.Lock()
+		entries := []LogEntry{}
+		for _, entry := range log {
+			if entry.ID > lastKnownID {
+				entries = append(entries, entry)
+			}
+		}
+		access.Unlock()
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		_ = json.NewEncoder(w).Encode(entries)
+	})
+	mux.HandleFunc("/api/v2/log/peers", func(w http.ResponseWriter, r *http.Request) {
+		lastKnownID := since(r)
+		access.Lock()
+		entries := []PeerLogEntry{}
+		for _, entry := range peers {
+			if entry.ID > lastKnownID {
+				entries = append(entries, entry)
+			}
+		}
+		access.Unlock()
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		_ = json.NewEncoder(w).Encode(entries)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	endpoint, _ := url.Parse(server.URL)
+	c, err := New(endpoint, "user", "pass")
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	firstRun := time.Unix(1700000000, 0)
+	appendLog(firstRun, "started", "listening", "loaded")
+	entries, errs := c.TailLog(ctx, nil, time.Millisecond)
+	next := func() (entry LogEntry, err error) {
+		select {
+		case entry = <-entries:
+		case err = <-errs:
+		case <-ctx.Done():
+			t.Fatal("timeout waiting for the follower")
+		}
+		return
+	}
+
+	// ── initial log then new entries ────────────────────────
+	for _, expected := range []string{"started", "listening", "loaded"} {
+		entry, err := next()
+		if err != nil || entry.Message != expected {
+			t.Fatalf("expected %q, got %+v (err: %v)", expected, entry, err)
+		}
+	}
+	appendLog(firstRun, "added torrent")
+	if entry, err := next(); err != nil || entry.Message != "added torrent" || entry.ID != 3 {
+		t.Fatalf("unexpected entry %+v (err: %v)", entry, err)
+	}
+
+	// ── restart ─────────────────────────────────────────────
+	access.Lock()
+	log = nil
+	access.Unlock()
+	appendLog(firstRun.Add(time.Hour), "restarted", "listening again")
+	if _, err := next(); !errors.Is(err, ErrLogRestarted) {
+		t.Fatalf("expected ErrLogRestarted, got %v", err)
+	}
+	for _, expected := range []string{"restarted", "listening again"} {
+		entry, err := next()
+		if err != nil || entry.Message != expected {
+			t.Fatalf("expected %q, got %+v (err: %v)", expected, entry, err)
+		}
+	}
+	// restarted log catching up on the last seen ID with a different entry
+	appendLog(firstRun.Add(time.Hour), "a", "b")
+	for _, expected := range []string{"a", "b"} {
+		if entry, err := next(); err != nil || entry.Message != expected {
+			t.Fatalf("expected %q, got %+v (err: %v)", expected, entry, err)
+		}
+	}
+	access.Lock()
+	log = nil
+	access.Unlock()
+	appendLog(firstRun.Add(2*time.Hour), "1", "2", "3", "4")
+	if _, err := next(); !errors.Is(err, ErrLogRestarted) {
+		t.Fatalf("expected ErrLogRestarted, got %v", err)
+	}
+	for _, expected := range []string{"1", "2", "3", "4"} {
+		if entry, err := next(); err != nil || entry.Message != expected {
+			t.Fatalf("expected %q, got %+v (err: %v)", expected, entry, err)
+		}
+	}
+
+	// ── peer log from a known ID ────────────────────────────
+	access.Lock()
+	for id, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		peers = append(peers, PeerLogEntry{ID: id, IP: ip, Timestamp: firstRun, Blocked: true})
+	}
+	access.Unlock()
+	peerCtx, peerCancel := context.WithCancel(ctx)
+	peerEntries, _ := c.TailPeerLog(peerCtx, &PeerLogFilters{LastKnownID: Int(1)}, time.Millisecond)
+	select {
+	case entry := <-peerEntries:
+		if entry.IP != "10.0.0.3" {
+			t.Errorf("unexpected peer entry: %+v", entry)
+		}
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for the peer follower")
+	}
+	peerCancel()
+	for range peerEntries {
+		// drained until closed
+	}
+
+	// ── channels closed on cancel ───────────────────────────
+	cancel()
+	for range entries {
+	}
+	if _, open := <-errs; open {
+		t.Error("expected errs to be closed")
+	}
+}
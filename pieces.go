@@ -0,0 +1,268 @@
+package qbtapi
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+/*
+	Torrent pieces map
+*/
+
+// PieceState represents the download state of a torrent piece.
+type PieceState uint8
+
+const (
+	PieceStateNotDownloaded PieceState = 0 // Not downloaded yet
+	PieceStateDownloading   PieceState = 1 // Being downloaded
+	PieceStateDownloaded    PieceState = 2 // Downloaded (and verified)
+)
+
+func (ps PieceState) String() string {
+	switch ps {
+	case PieceStateNotDownloaded:
+		return "NotDownloaded"
+	case PieceStateDownloading:
+		return "Downloading"
+	case PieceStateDownloaded:
+		return "Downloaded"
+	default:
+		return "Unknown"
+	}
+}
+
+// PieceMap contains the state of each piece of a torrent, indexed by piece index.
+type PieceMap []PieceState
+
+// NewPieceMap converts the raw states returned by GetTorrentPiecesStates().
+func NewPieceMap(states []int) PieceMap {
+	pm := make(PieceMap, len(states))
+	for index, state := range states {
+		pm[index] = PieceState(state)
+	}
+	return pm
+}
+
+// GetTorrentPieceMap returns the pieces states of a torrent as a PieceMap.
+func (c *Client) GetTorrentPieceMap(ctx context.Context, hash string) (pieces PieceMap, err error) {
+	states, err := c.GetTorrentPiecesStates(ctx, hash)
+	if err != nil {
+		return
+	}
+	pieces = NewPieceMap(states)
+	return
+}
+
+// PieceCounts contains the number of pieces in each state.
+type PieceCounts struct {
+	NotDownloaded int
+	Downloading   int
+	Downloaded    int
+}
+
+// Total returns the total number of pieces.
+func (pc PieceCounts) Total() int {
+	return pc.NotDownloaded + pc.Downloading + pc.Downloaded
+}
+
+// Counts returns the number of pieces in each state.
+func (pm PieceMap) Counts() (counts PieceCounts) {
+	return pm.countRange(0, len(pm)-1)
+}
+
+func (pm PieceMap) countRange(first, last int) (counts PieceCounts) {
+	for index := max(first, 0); index <= last && index < len(pm); index++ {
+		switch pm[index] {
+		case PieceStateDownloading:
+			counts.Downloading++
+		case PieceStateDownloaded:
+			counts.Downloaded++
+		default:
+			counts.NotDownloaded++
+		}
+	}
+	return
+}
+
+// PieceRange is a range of contiguous pieces in the same state.
+type PieceRange struct {
+	First int // First piece index
+	Last  int // Last piece index (inclusive)
+	State PieceState
+}
+
+// Len returns the number of pieces of the range.
+func (pr PieceRange) Len() int {
+	return pr.Last - pr.First + 1
+}
+
+// Ranges compresses the map into ranges of contiguous pieces sharing the same state, in pieces order.
+func (pm PieceMap) Ranges() (ranges []PieceRange) {
+	for index, state := range pm {
+		if len(ranges) > 0 && ranges[len(ranges)-1].State == state {
+			ranges[len(ranges)-1].Last = index
+			continue
+		}
+		ranges = append(ranges, PieceRange{
+			First: index,
+			Last:  index,
+			State: state,
+		})
+	}
+	return
+}
+
+// FilePieces describes the pieces state of a torrent file.
+type FilePieces struct {
+	File                TorrentContent
+	Counts              PieceCounts  // States of the file pieces (pieces shared with other files included)
+	Missing             []PieceRange // Ranges of the file pieces not downloaded yet (including the ones being downloaded)
+	ContiguousFromStart int          // Number of pieces downloaded from the beginning of the file without gap
+}
+
+// Complete returns true if all the pieces of the file are downloaded.
+func (fp FilePieces) Complete() bool {
+	return fp.Counts.Total() > 0 && fp.Counts.Downloaded == fp.Counts.Total()
+}
+
+// Completeness returns the ratio of downloaded pieces of the file.
+func (fp FilePieces) Completeness() float64 {
+	if fp.Counts.Total() == 0 {
+		return 0
+	}
+	return float64(fp.Counts.Downloaded) / float64(fp.Counts.Total())
+}
+
+// Files maps the pieces to the files of the torrent using their PieceRange. Files with an invalid
+// piece range (or outside of the map) have no pieces.
+func (pm PieceMap) Files(contents []TorrentContent) (files []FilePieces) {
+	files = make([]FilePieces, len(contents))
+	for index, content := range contents {
+		files[index].File = content
+		if len(content.PieceRange) != 2 {
+			continue
+		}
+		first, last := max(content.PieceRange[0], 0), min(content.PieceRange[1], len(pm)-1)
+		if first > last {
+			continue
+		}
+		files[index].Counts = pm.countRange(first, last)
+		for _, pieceRange := range pm[first : last+1].Ranges() {
+			if pieceRange.State == PieceStateDownloaded {
+				if pieceRange.First == 0 {
+					files[index].ContiguousFromStart = pieceRange.Len()
+				}
+				continue
+			}
+			pieceRange.First += first
+			pieceRange.Last += first
+			files[index].Missing = append(files[index].Missing, pieceRange)
+		}
+	}
+	return
+}
+
+// Piece bar charsets for Bar(), from empty to full.
+var (
+	PieceBarASCII   = []rune(" .:=#")
+	PieceBarUnicode = []rune(" ░▒▓█")
+)
+
+// Bar renders the map as a bar of width characters (one per piece if width is 0 or greater than the
+// number of pieces) using charset (see PieceBarASCII and PieceBarUnicode). Each character represents
+// the downloaded ratio of its pieces, pieces being downloaded counting for half: the first character
+// of the charset is only used for cells without any progress and the last one for complete cells.
+func (pm PieceMap) Bar(width int, charset []rune) string {
+	if len(charset) < 2 {
+		charset = PieceBarASCII
+	}
+	var builder strings.Builder
+	for _, ratio := range pm.cells(width) {
+		var level int
+		switch {
+		case ratio <= 0:
+			level = 0
+		case ratio >= 1:
+			level = len(charset) - 1
+		case len(charset) == 2:
+			level = 0
+		default:
+			// intermediate characters share the ]0;1[ interval
+			level = 1 + int(ratio*float64(len(charset)-2))
+		}
+		builder.WriteRune(charset[level])
+	}
+	return builder.String()
+}
+
+// cells splits the map into width cells (one per piece if width is 0 or greater than the number of pieces)
+// and returns the downloaded ratio of each cell, pieces being downloaded counting for half.
+func (pm PieceMap) cells(width int) (ratios []float64) {
+	if width <= 0 || width > len(pm) {
+		width = len(pm)
+	}
+	ratios = make([]float64, width)
+	for cell := range ratios {
+		first, last := cell*len(pm)/width, (cell+1)*len(pm)/width-1
+		counts := pm.countRange(first, last)
+		ratios[cell] = (float64(counts.Downloaded) + float64(counts.Downloading)/2) / float64(counts.Total())
+	}
+	return
+}
+
+// Colors used by Image().
+var (
+	PieceColorNotDownloaded = color.RGBA{R: 0xee, G: 0xee, B: 0xee, A: 0xff}
+	PieceColorDownloading   = color.RGBA{R: 0x4c, G: 0xaf, B: 0x50, A: 0xff}
+	PieceColorDownloaded    = color.RGBA{R: 0x21, G: 0x96, B: 0xf3, A: 0xff}
+)
+
+// Image renders the map as a width x height image (width defaults to the number of pieces if 0), each column
+// blending the colors of its pieces states (see PieceColorNotDownloaded, PieceColorDownloading and PieceColorDownloaded).
+func (pm PieceMap) Image(width, height int) image.Image {
+	if width <= 0 {
+		width = len(pm)
+	}
+	if height <= 0 {
+		height = 1
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	if len(pm) == 0 {
+		return img
+	}
+	for x := 0; x < width; x++ {
+		// pieces covered by the column (at least one)
+		first := x * len(pm) / width
+		last := max((x+1)*len(pm)/width-1, first)
+		counts := pm.countRange(first, last)
+		total := float64(counts.Total())
+		blend := func(channel func(color.RGBA) uint8) uint8 {
+			return uint8((float64(channel(PieceColorNotDownloaded))*float64(counts.NotDownloaded) +
+				float64(channel(PieceColorDownloading))*float64(counts.Downloading) +
+				float64(channel(PieceColorDownloaded))*float64(counts.Downloaded)) / total)
+		}
+		columnColor := color.RGBA{
+			R: blend(func(c color.RGBA) uint8 { return c.R }),
+			G: blend(func(c color.RGBA) uint8 { return c.G }),
+			B: blend(func(c color.RGBA) uint8 { return c.B }),
+			A: 0xff,
+		}
+		for y := 0; y < height; y++ {
+			img.SetRGBA(x, y, columnColor)
+		}
+	}
+	return img
+}
+
+// WritePNG writes the map rendered by Image() to w as a PNG image.
+func (pm PieceMap) WritePNG(w io.Writer, width, height int) (err error) {
+	if err = png.Encode(w, pm.Image(width, height)); err != nil {
+		err = fmt.Errorf("encoding PNG failed: %w", err)
+	}
+	return
+}
@@ -0,0 +1,80 @@
+package qbtapi
+
+import (
+	"bytes"
+	"image/png"
+	"slices"
+	"testing"
+)
+
+func TestPieceMap(t *testing.T) {
+	pieces := NewPieceMap([]int{2, 2, 2, 1, 0, 0, 2, 2, 0, 2})
+
+	// ── counts and ranges ───────────────────────────────────
+	if counts := pieces.Counts(); counts != (PieceCounts{NotDownloaded: 3, Downloading: 1, Downloaded: 6}) || counts.Total() != 10 {
+		t.Errorf("unexpected counts: %+v", counts)
+	}
+	expectedRanges := []PieceRange{
+		{0, 2, PieceStateDownloaded},
+		{3, 3, PieceStateDownloading},
+		{4, 5, PieceStateNotDownloaded},
+		{6, 7, PieceStateDownloaded},
+		{8, 8, PieceStateNotDownloaded},
+		{9, 9, PieceStateDownloaded},
+	}
+	if ranges := pieces.Ranges(); !slices.Equal(ranges, expectedRanges) {
+		t.Errorf("unexpected ranges: %+v", ranges)
+	}
+
+	// ── files ───────────────────────────────────────────────
+	files := pieces.Files([]TorrentContent{
+		{Index: 0, Name: "a.mkv", PieceRange: []int{0, 4}},
+		{Index: 1, Name: "b.mkv", PieceRange: []int{4, 7}},
+		{Index: 2, Name: "c.nfo", PieceRange: []int{9, 9}},
+		{Index: 3, Name: "broken", PieceRange: []int{12, 15}},
+	})
+	if files[0].Complete() || files[0].ContiguousFromStart != 3 || files[0].Completeness() != 0.6 {
+		t.Errorf("unexpected a.mkv pieces: %+v", files[0])
+	}
+	if expected := []PieceRange{{3, 3, PieceStateDownloading}, {4, 4, PieceStateNotDownloaded}}; !slices.Equal(files[0].Missing, expected) {
+		t.Errorf("unexpected a.mkv missing ranges: %+v", files[0].Missing)
+	}
+	if files[1].ContiguousFromStart != 0 || !slices.Equal(files[1].Missing, []PieceRange{{4, 5, PieceStateNotDownloaded}}) {
+		t.Errorf("unexpected b.mkv pieces: %+v", files[1])
+	}
+	if !files[2].Complete() || files[2].ContiguousFromStart != 1 {
+		t.Errorf("unexpected c.nfo pieces: %+v", files[2])
+	}
+	if files[3].Counts.Total() != 0 || files[3].Complete() {
+		t.Errorf("unexpected broken file pieces: %+v", files[3])
+	}
+
+	// ── bar ─────────────────────────────────────────────────
+	if bar := pieces.Bar(0, PieceBarASCII); bar != "###:  ## #" {
+		t.Errorf("unexpected full width bar: %q", bar)
+	}
+	// cells of 2 pieces: [2 2] [2 1] [0 0] [2 2] [0 2]
+	if bar := pieces.Bar(5, PieceBarUnicode); bar != "█▓ █▒" {
+		t.Errorf("unexpected compact bar: %q", bar)
+	}
+
+	// ── image ───────────────────────────────────────────────
+	var buffer bytes.Buffer
+	if err := pieces.WritePNG(&buffer, 20, 4); err != nil {
+		t.Fatalf("WritePNG: %v", err)
+	}
+	img, err := png.Decode(&buffer)
+	if err != nil {
+		t.Fatalf("decoding PNG: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 20 || bounds.Dy() != 4 {
+		t.Errorf("unexpected image size: %v", bounds)
+	}
+	r, g, b, _ := img.At(0, 0).RGBA()
+	if uint8(r>>8) != PieceColorDownloaded.R || uint8(g>>8) != PieceColorDownloaded.G || uint8(b>>8) != PieceColorDownloaded.B {
+		t.Errorf("unexpected first column color: %v", img.At(0, 0))
+	}
+	if compact := pieces.Image(5, 0).Bounds(); compact.Dx() != 5 || compact.Dy() != 1 {
+		t.Errorf("unexpected compact image size: %v", compact)
+	}
+}
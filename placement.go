@@ -0,0 +1,307 @@
+package qbtapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"sync"
+
+	"github.com/hekmon/cunits/v3"
+)
+
+/*
+	Load aware placement of new torrents across a pool
+*/
+
+// PlacementCandidate holds the load metrics of an instance, as used by placement strategies.
+type PlacementCandidate struct {
+	Instance          string      // Instance name within the pool
+	FreeSpace         cunits.Bits // Free space on the default save path disk
+	TorrentCount      int         // Number of torrents on the instance
+	ActiveDownloads   int         // Number of torrents currently downloading (queued and stopped ones excluded)
+	DownloadSpeed     Speed       // Current global download speed
+	UploadSpeed       Speed       // Current global upload speed
+	HasCategory       bool        // True if the requested category exists on the instance
+	CategoryTorrents  int         // Number of torrents within the requested category
+	UseAltSpeedLimits bool        // True if alternative speed limits are enabled
+}
+
+// PlacementRequest describes the torrent(s) to place.
+type PlacementRequest struct {
+	Category string      // Category the torrent will be assigned to (optional, used for category affinity)
+	Size     cunits.Bits // Expected size of the torrent (optional, instances without enough free space are excluded)
+}
+
+// PlacementStrategy scores every candidate for a given request: the candidate with the highest score wins.
+// The returned slice must have the same length and order as candidates.
+type PlacementStrategy func(request PlacementRequest, candidates []PlacementCandidate) (scores []float64)
+
+// PlaceByFreeSpace favors instances with the most free disk space.
+func PlaceByFreeSpace(request PlacementRequest, candidates []PlacementCandidate) (scores []float64) {
+	scores = make([]float64, len(candidates))
+	for index, candidate := range candidates {
+		scores[index] = candidate.FreeSpace.Bytes()
+	}
+	return
+}
+
+// PlaceByTorrentCount favors instances with the fewest torrents.
+func PlaceByTorrentCount(request PlacementRequest, candidates []PlacementCandidate) (scores []float64) {
+	scores = make([]float64, len(candidates))
+	for index, candidate := range candidates {
+		scores[index] = -float64(candidate.TorrentCount)
+	}
+	return
+}
+
+// PlaceByActiveDownloads favors instances with the fewest active downloads.
+func PlaceByActiveDownloads(request PlacementRequest, candidates []PlacementCandidate) (scores []float64) {
+	scores = make([]float64, len(candidates))
+	for index, candidate := range candidates {
+		scores[index] = -float64(candidate.ActiveDownloads)
+	}
+	return
+}
+
+// PlaceByDownloadSpeed favors instances with the lowest global download speed.
+func PlaceByDownloadSpeed(request PlacementRequest, candidates []PlacementCandidate) (scores []float64) {
+	scores = make([]float64, len(candidates))
+	for index, candidate := range candidates {
+		scores[index] = -candidate.DownloadSpeed.Bytes()
+	}
+	return
+}
+
+// PlaceByCategoryAffinity favors instances already holding the requested category, then the ones
+// with the most torrents within it. All candidates score the same if the request has no category.
+func PlaceByCategoryAffinity(request PlacementRequest, candidates []PlacementCandidate) (scores []float64) {
+	scores = make([]float64, len(candidates))
+	if request.Category == "" {
+		return
+	}
+	for index, candidate := range candidates {
+		if candidate.HasCategory {
+			// existing category always beats a higher torrent count elsewhere
+			scores[index] = 1 + float64(candidate.CategoryTorrents)/float64(candidate.CategoryTorrents+1)
+		} else if candidate.CategoryTorrents > 0 {
+			scores[index] = float64(candidate.CategoryTorrents) / float64(candidate.CategoryTorrents+1)
+		}
+	}
+	return
+}
+
+// WeightedPlacementStrategy associates a strategy with its weight within WeightedPlacement().
+type WeightedPlacementStrategy struct {
+	Strategy PlacementStrategy
+	Weight   float64
+}
+
+// WeightedPlacement combines several strategies: the scores of each strategy are normalized to [0, 1]
+// across the candidates (min-max) before being weighted and summed.
+func WeightedPlacement(strategies ...WeightedPlacementStrategy) PlacementStrategy {
+	return func(request PlacementRequest, candidates []PlacementCandidate) (scores []float64) {
+		scores = make([]float64, len(candidates))
+		for _, ws := range strategies {
+			raw := ws.Strategy(request, candidates)
+			minScore, maxScore := math.Inf(1), math.Inf(-1)
+			for _, score := range raw {
+				minScore = math.Min(minScore, score)
+				maxScore = math.Max(maxScore, score)
+			}
+			if maxScore == minScore {
+				// this strategy does not discriminate between candidates
+				continue
+			}
+			for index, score := range raw {
+				scores[index] += ws.Weight * (score - minScore) / (maxScore - minScore)
+			}
+		}
+		return
+	}
+}
+
+// DefaultPlacementStrategy is used by placers created without an explicit strategy.
+var DefaultPlacementStrategy = WeightedPlacement(
+	WeightedPlacementStrategy{Strategy: PlaceByFreeSpace, Weight: 1},
+	WeightedPlacementStrategy{Strategy: PlaceByActiveDownloads, Weight: 1},
+	WeightedPlacementStrategy{Strategy: PlaceByCategoryAffinity, Weight: 1},
+	WeightedPlacementStrategy{Strategy: PlaceByTorrentCount, Weight: 0.5},
+	WeightedPlacementStrategy{Strategy: PlaceByDownloadSpeed, Weight: 0.5},
+)
+
+// Placer picks the instance of a pool which should receive new torrents.
+// Must be instanciated with NewPlacer().
+type Placer struct {
+	pool         *Pool
+	strategy     PlacementStrategy
+	minFreeSpace cunits.Bits
+}
+
+// NewPlacer returns a placer for the given pool. If strategy is nil, DefaultPlacementStrategy is used.
+// minFreeSpace is the free space an instance must keep after receiving the torrent to be eligible.
+func NewPlacer(pool *Pool, strategy PlacementStrategy, minFreeSpace cunits.Bits) *Placer {
+	if strategy == nil {
+		strategy = DefaultPlacementStrategy
+	}
+	return &Placer{
+		pool:         pool,
+		strategy:     strategy,
+		minFreeSpace: minFreeSpace,
+	}
+}
+
+// PlacementScore is the score obtained by a candidate.
+type PlacementScore struct {
+	PlacementCandidate
+	Score    float64
+	Eligible bool // False if the candidate did not have enough free space
+}
+
+// PlacementDecision explains where a torrent would go and why.
+type PlacementDecision struct {
+	Instance string           // Chosen instance
+	Scores   []PlacementScore // All candidates, best first
+	Failures *PoolError       // Instances which could not be evaluated, nil if none
+}
+
+// ErrNoEligibleInstance is returned when no instance of the pool can receive a torrent.
+var ErrNoEligibleInstance = errors.New("no eligible instance")
+
+var activeDownloadStates = map[TorrentState]struct{}{
+	TorrentStateDownloading:         {},
+	TorrentStateMetadataDownloading: {},
+	TorrentStateForcedDownloading:   {},
+	TorrentStateStalledDownloading:  {},
+	TorrentStateCheckingDownloading: {},
+	TorrentStateAllocating:          {},
+}
+
+// Candidates collects the load metrics of every instance of the pool. Instances failing to
+// answer are reported within the returned *PoolError while the others are still returned.
+func (pl *Placer) Candidates(ctx context.Context, request PlacementRequest) (candidates []PlacementCandidate, err error) {
+	results := make(map[string]PlacementCandidate, len(pl.pool.names))
+	var resultsAccess sync.Mutex
+	err = pl.pool.Do(ctx, func(ctx context.Context, name string, client *Client) error {
+		data, err := client.GetMainData(ctx, 0)
+		if err != nil {
+			return err
+		}
+		candidate := PlacementCandidate{
+			Instance:          name,
+			FreeSpace:         data.ServerState.FreeSpaceOnDisk,
+			TorrentCount:      len(data.Torrents),
+			DownloadSpeed:     data.ServerState.DlInfoSpeed,
+			UploadSpeed:       data.ServerState.UpInfoSpeed,
+			UseAltSpeedLimits: data.ServerState.UseAltSpeedLimits,
+		}
+		if request.Category != "" {
+			_, candidate.HasCategory = data.Categories[request.Category]
+		}
+		for _, torrent := range data.Torrents {
+			if _, active := activeDownloadStates[torrent.State]; active {
+				candidate.ActiveDownloads++
+			}
+			if request.Category != "" && torrent.Category == request.Category {
+				candidate.CategoryTorrents++
+			}
+		}
+		resultsAccess.Lock()
+		results[name] = candidate
+		resultsAccess.Unlock()
+		return nil
+	})
+	for _, name := range pl.pool.names {
+		if candidate, found := results[name]; found {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return
+}
+
+// Plan computes where a torrent matching request would be placed, without adding anything (dry run).
+func (pl *Placer) Plan(ctx context.Context, request PlacementRequest) (decision PlacementDecision, err error) {
+	candidates, err := pl.Candidates(ctx, request)
+	if err != nil {
+		if !errors.As(err, &decision.Failures) {
+			return
+		}
+		err = nil
+	}
+	decision.Scores, decision.Instance = pl.rank(request, candidates)
+	if decision.Instance == "" {
+		if decision.Failures != nil {
+			err = fmt.Errorf("%w: %w", ErrNoEligibleInstance, decision.Failures)
+		} else {
+			err = ErrNoEligibleInstance
+		}
+	}
+	return
+}
+
+func (pl *Placer) rank(request PlacementRequest, candidates []PlacementCandidate) (ranked []PlacementScore, best string) {
+	// filter out candidates without enough space
+	eligibles := make([]PlacementCandidate, 0, len(candidates))
+	ranked = make([]PlacementScore, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate.FreeSpace < request.Size+pl.minFreeSpace {
+			ranked = append(ranked, PlacementScore{
+				PlacementCandidate: candidate,
+				Score:              math.Inf(-1),
+			})
+			continue
+		}
+		eligibles = append(eligibles, candidate)
+	}
+	if len(eligibles) > 0 {
+		scores := pl.strategy(request, eligibles)
+		for index, candidate := range eligibles {
+			ranked = append(ranked, PlacementScore{
+				PlacementCandidate: candidate,
+				Score:              scores[index],
+				Eligible:           true,
+			})
+		}
+	}
+	// best first, ties broken by instance name for stable decisions
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Eligible != ranked[j].Eligible {
+			return ranked[i].Eligible
+		}
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Instance < ranked[j].Instance
+	})
+	if len(ranked) > 0 && ranked[0].Eligible {
+		best = ranked[0].Instance
+	}
+	return
+}
+
+// AddNewTorrents places the torrents on the best instance for request and adds them there.
+// If request has a category and options does not, the request category is applied to the torrents.
+// See Client.AddNewTorrents() for the files, urls and options parameters.
+func (pl *Placer) AddNewTorrents(ctx context.Context, request PlacementRequest, files map[string][]byte, urls []*url.URL, options *AddNewTorrentsOptions) (decision PlacementDecision, err error) {
+	if options != nil && options.Category != nil && request.Category == "" {
+		request.Category = *options.Category
+	}
+	if decision, err = pl.Plan(ctx, request); err != nil {
+		err = fmt.Errorf("placing torrents failed: %w", err)
+		return
+	}
+	if request.Category != "" && (options == nil || options.Category == nil) {
+		var optionsCopy AddNewTorrentsOptions
+		if options != nil {
+			optionsCopy = *options
+		}
+		optionsCopy.Category = String(request.Category)
+		options = &optionsCopy
+	}
+	if err = pl.pool.Client(decision.Instance).AddNewTorrents(ctx, files, urls, options); err != nil {
+		err = fmt.Errorf("adding torrents to instance %q failed: %w", decision.Instance, err)
+	}
+	return
+}
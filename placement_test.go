@@ -0,0 +1,83 @@
+package qbtapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hekmon/cunits/v3"
+)
+
+func TestPlacementStrategies(t *testing.T) {
+	candidates := []PlacementCandidate{
+		{
+			Instance:        "alpha",
+			FreeSpace:       cunits.ImportInGiB(500),
+			TorrentCount:    100,
+			ActiveDownloads: 5,
+		},
+		{
+			Instance:         "beta",
+			FreeSpace:        cunits.ImportInGiB(200),
+			TorrentCount:     10,
+			ActiveDownloads:  0,
+			HasCategory:      true,
+			CategoryTorrents: 3,
+		},
+		{
+			Instance:  "gamma",
+			FreeSpace: cunits.ImportInGiB(5),
+		},
+	}
+	request := PlacementRequest{
+		Category: "linux",
+		Size:     cunits.ImportInGiB(10),
+	}
+
+	// ── single strategies ───────────────────────────────────
+	placer := NewPlacer(nil, PlaceByFreeSpace, 0)
+	ranked, best := placer.rank(request, candidates)
+	if best != "alpha" {
+		t.Fatalf("free space strategy: expected 'alpha', got %q", best)
+	}
+	if last := ranked[len(ranked)-1]; last.Instance != "gamma" || last.Eligible {
+		t.Fatalf("expected 'gamma' to be ranked last and not eligible, got %+v", last)
+	}
+	placer = NewPlacer(nil, PlaceByCategoryAffinity, 0)
+	if _, best = placer.rank(request, candidates); best != "beta" {
+		t.Fatalf("category affinity strategy: expected 'beta', got %q", best)
+	}
+
+	// ── default weighted strategy ───────────────────────────
+	placer = NewPlacer(nil, nil, 0)
+	if _, best = placer.rank(request, candidates); best != "beta" {
+		t.Fatalf("default strategy: expected 'beta', got %q", best)
+	}
+
+	// ── min free space ──────────────────────────────────────
+	placer = NewPlacer(nil, nil, cunits.ImportInGiB(300))
+	if _, best = placer.rank(request, candidates); best != "alpha" {
+		t.Fatalf("min free space: expected 'alpha', got %q", best)
+	}
+	placer = NewPlacer(nil, nil, cunits.ImportInGiB(1000))
+	if _, best = placer.rank(request, candidates); best != "" {
+		t.Fatalf("min free space: expected no eligible instance, got %q", best)
+	}
+}
+
+func TestPlacementPlan(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	pool, err := NewPool(map[string]*Client{"main": c})
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	decision, err := NewPlacer(pool, nil, 0).Plan(ctx, PlacementRequest{})
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if decision.Instance != "main" {
+		t.Fatalf("expected instance 'main', got %q", decision.Instance)
+	}
+	t.Logf("placement scores: %+v", decision.Scores)
+}
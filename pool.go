@@ -0,0 +1,407 @@
+package qbtapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*
+	Multi instances pool
+*/
+
+// Pool wraps several named clients (one per qBittorrent instance) and allows to fan out calls to all of them.
+// Fan out methods tolerate partial failures: results of the healthy instances are returned alongside
+// a *PoolError holding the per instance errors.
+// Must be instanciated with NewPool().
+type Pool struct {
+	clients map[string]*Client
+	names   []string
+	// hash -> instance name cache, filled by torrent listings and lookups
+	ownersAccess sync.Mutex
+	owners       map[string]string
+}
+
+// NewPool returns a pool of the given clients, indexed by their instance name.
+func NewPool(clients map[string]*Client) (p *Pool, err error) {
+	if len(clients) == 0 {
+		err = errors.New("at least one client is required")
+		return
+	}
+	p = &Pool{
+		clients: make(map[string]*Client, len(clients)),
+		names:   make([]string, 0, len(clients)),
+		owners:  make(map[string]string),
+	}
+	for name, client := range clients {
+		if client == nil {
+			p = nil
+			err = fmt.Errorf("client %q is nil", name)
+			return
+		}
+		p.clients[name] = client
+		p.names = append(p.names, name)
+	}
+	sort.Strings(p.names)
+	return
+}
+
+// Names returns the instance names of the pool, sorted.
+func (p *Pool) Names() []string {
+	names := make([]string, len(p.names))
+	copy(names, p.names)
+	return names
+}
+
+// Client returns the client of a given instance or nil if the instance is unknown.
+func (p *Pool) Client(name string) *Client {
+	return p.clients[name]
+}
+
+// PoolError contains the errors encountered on each failed instance during a fan out call.
+type PoolError struct {
+	Errors map[string]error // instance name -> error
+}
+
+func (pe *PoolError) Error() string {
+	names := make([]string, 0, len(pe.Errors))
+	for name := range pe.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for index, name := range names {
+		parts[index] = fmt.Sprintf("%s: %s", name, pe.Errors[name])
+	}
+	return fmt.Sprintf("%d instance(s) failed: %s", len(pe.Errors), strings.Join(parts, "; "))
+}
+
+// Unwrap allows errors.Is() and errors.As() to inspect each instance error.
+func (pe *PoolError) Unwrap() []error {
+	errs := make([]error, 0, len(pe.Errors))
+	for _, err := range pe.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Do calls fn concurrently for every instance of the pool and waits for all of them to return.
+// If at least one call fails, a *PoolError is returned.
+func (p *Pool) Do(ctx context.Context, fn func(ctx context.Context, name string, client *Client) error) error {
+	return p.do(ctx, p.names, fn)
+}
+
+func (p *Pool) do(ctx context.Context, names []string, fn func(ctx context.Context, name string, client *Client) error) error {
+	var (
+		wg         sync.WaitGroup
+		errsAccess sync.Mutex
+		errs       map[string]error
+	)
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			if err := fn(ctx, name, p.clients[name]); err != nil {
+				errsAccess.Lock()
+				if errs == nil {
+					errs = make(map[string]error)
+				}
+				errs[name] = err
+				errsAccess.Unlock()
+			}
+		}(name)
+	}
+	wg.Wait()
+	if errs != nil {
+		return &PoolError{Errors: errs}
+	}
+	return nil
+}
+
+/*
+	Fan out queries
+*/
+
+// marshalWithInstance marshals value (which must marshal to a JSON object) and adds the instance name to it.
+// Needed by the pool wrappers as the MarshalJSON() of their embedded type would be promoted, dropping Instance.
+func marshalWithInstance(value any, instance string) (data []byte, err error) {
+	name, err := json.Marshal(instance)
+	if err != nil {
+		return
+	}
+	return marshalWithUnknownFields(value, map[string]json.RawMessage{"instance": name})
+}
+
+// unmarshalWithInstance unmarshals data into value and its instance name into instance.
+func unmarshalWithInstance(data []byte, value any, instance *string) (err error) {
+	if err = json.Unmarshal(data, value); err != nil {
+		return
+	}
+	var tmp struct {
+		Instance string `json:"instance"`
+	}
+	if err = json.Unmarshal(data, &tmp); err != nil {
+		return
+	}
+	*instance = tmp.Instance
+	return
+}
+
+// PoolTorrentInfos is a TorrentInfos tagged with the instance it belongs to.
+type PoolTorrentInfos struct {
+	Instance string `json:"instance"`
+	TorrentInfos
+}
+
+// MarshalJSON implements json.Marshaler to add the instance name to the torrent fields.
+func (pti *PoolTorrentInfos) MarshalJSON() ([]byte, error) {
+	return marshalWithInstance(&pti.TorrentInfos, pti.Instance)
+}
+
+// UnmarshalJSON implements json.Unmarshaler to read the instance name alongside the torrent fields.
+func (pti *PoolTorrentInfos) UnmarshalJSON(data []byte) error {
+	return unmarshalWithInstance(data, &pti.TorrentInfos, &pti.Instance)
+}
+
+// GetTorrentList returns the merged torrent listing of all instances. filters are optional and can be nil.
+// Returned torrents are grouped by instance (following Names() order), each group keeping the server order.
+// Listing also refreshes the hash ownership cache used to route hash based actions.
+func (p *Pool) GetTorrentList(ctx context.Context, filters *ListFilters) (list []PoolTorrentInfos, err error) {
+	results := make(map[string][]TorrentInfos, len(p.names))
+	var resultsAccess sync.Mutex
+	err = p.Do(ctx, func(ctx context.Context, name string, client *Client) error {
+		torrents, err := client.GetTorrentList(ctx, filters)
+		if err != nil {
+			return err
+		}
+		resultsAccess.Lock()
+		results[name] = torrents
+		resultsAccess.Unlock()
+		return nil
+	})
+	p.ownersAccess.Lock()
+	for _, name := range p.names {
+		for _, torrent := range results[name] {
+			list = append(list, PoolTorrentInfos{
+				Instance:     name,
+				TorrentInfos: torrent,
+			})
+			p.owners[strings.ToLower(torrent.Hash)] = name
+		}
+	}
+	p.ownersAccess.Unlock()
+	return
+}
+
+// PoolGlobalTransferInfo is a GlobalTransferInfo tagged with the instance it belongs to.
+type PoolGlobalTransferInfo struct {
+	Instance string `json:"instance"`
+	GlobalTransferInfo
+}
+
+// MarshalJSON implements json.Marshaler to add the instance name to the transfer info fields.
+func (pgti PoolGlobalTransferInfo) MarshalJSON() ([]byte, error) {
+	return marshalWithInstance(pgti.GlobalTransferInfo, pgti.Instance)
+}
+
+// UnmarshalJSON implements json.Unmarshaler to read the instance name alongside the transfer info fields.
+func (pgti *PoolGlobalTransferInfo) UnmarshalJSON(data []byte) error {
+	return unmarshalWithInstance(data, &pgti.GlobalTransferInfo, &pgti.Instance)
+}
+
+// GetGlobalTransferInfo returns the global transfer information of all instances, following Names() order.
+func (p *Pool) GetGlobalTransferInfo(ctx context.Context) (infos []PoolGlobalTransferInfo, err error) {
+	results := make(map[string]GlobalTransferInfo, len(p.names))
+	var resultsAccess sync.Mutex
+	err = p.Do(ctx, func(ctx context.Context, name string, client *Client) error {
+		info, err := client.GetGlobalTransferInfo(ctx)
+		if err != nil {
+			return err
+		}
+		resultsAccess.Lock()
+		results[name] = info
+		resultsAccess.Unlock()
+		return nil
+	})
+	for _, name := range p.names {
+		if info, found := results[name]; found {
+			infos = append(infos, PoolGlobalTransferInfo{
+				Instance:           name,
+				GlobalTransferInfo: info,
+			})
+		}
+	}
+	return
+}
+
+// PoolLogEntry is a LogEntry tagged with the instance it belongs to.
+type PoolLogEntry struct {
+	Instance string `json:"instance"`
+	LogEntry
+}
+
+// MarshalJSON implements json.Marshaler to add the instance name to the log entry fields.
+func (ple PoolLogEntry) MarshalJSON() ([]byte, error) {
+	return marshalWithInstance(ple.LogEntry, ple.Instance)
+}
+
+// UnmarshalJSON implements json.Unmarshaler to read the instance name alongside the log entry fields.
+func (ple *PoolLogEntry) UnmarshalJSON(data []byte) error {
+	return unmarshalWithInstance(data, &ple.LogEntry, &ple.Instance)
+}
+
+// GetLog returns the merged log entries of all instances, sorted by timestamp. filters are optional and can be nil.
+// Note that LastKnownID is sent as is to every instance: log IDs are not comparable between instances.
+func (p *Pool) GetLog(ctx context.Context, filters *LogFilters) (entries []PoolLogEntry, err error) {
+	var entriesAccess sync.Mutex
+	err = p.Do(ctx, func(ctx context.Context, name string, client *Client) error {
+		logEntries, err := client.GetLog(ctx, filters)
+		if err != nil {
+			return err
+		}
+		entriesAccess.Lock()
+		for _, entry := range logEntries {
+			entries = append(entries, PoolLogEntry{
+				Instance: name,
+				LogEntry: entry,
+			})
+		}
+		entriesAccess.Unlock()
+		return nil
+	})
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Timestamp.Equal(entries[j].Timestamp) {
+			if entries[i].Instance == entries[j].Instance {
+				return entries[i].ID < entries[j].ID
+			}
+			return entries[i].Instance < entries[j].Instance
+		}
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	return
+}
+
+/*
+	Hash based routing
+*/
+
+// ErrTorrentNotFound is returned when a torrent hash could not be found on any instance of a pool.
+var ErrTorrentNotFound = errors.New("torrent not found on any instance")
+
+// FindTorrentOwner returns the name of the instance owning the given torrent hash.
+// The ownership cache is used first, instances are queried if the hash is unknown.
+func (p *Pool) FindTorrentOwner(ctx context.Context, hash string) (name string, err error) {
+	owners, lookupErr := p.findOwners(ctx, []string{hash})
+	if name = owners[strings.ToLower(hash)]; name != "" {
+		return
+	}
+	if lookupErr != nil {
+		err = fmt.Errorf("looking up hash failed: %w", lookupErr)
+	} else {
+		err = fmt.Errorf("%s: %w", hash, ErrTorrentNotFound)
+	}
+	return
+}
+
+func (p *Pool) findOwners(ctx context.Context, hashes []string) (owners map[string]string, err error) {
+	owners = make(map[string]string, len(hashes))
+	var unknown []string
+	p.ownersAccess.Lock()
+	for _, hash := range hashes {
+		hash = strings.ToLower(hash)
+		if name, found := p.owners[hash]; found {
+			owners[hash] = name
+		} else {
+			unknown = append(unknown, hash)
+		}
+	}
+	p.ownersAccess.Unlock()
+	if len(unknown) == 0 {
+		return
+	}
+	// query every instance for the unknown hashes
+	var ownersAccess sync.Mutex
+	err = p.Do(ctx, func(ctx context.Context, name string, client *Client) error {
+		torrents, err := client.GetTorrentList(ctx, &ListFilters{Hashes: unknown})
+		if err != nil {
+			return err
+		}
+		ownersAccess.Lock()
+		for _, torrent := range torrents {
+			owners[strings.ToLower(torrent.Hash)] = name
+		}
+		ownersAccess.Unlock()
+		return nil
+	})
+	p.ownersAccess.Lock()
+	for hash, name := range owners {
+		p.owners[hash] = name
+	}
+	p.ownersAccess.Unlock()
+	return
+}
+
+// ForgetTorrentOwners removes the given hashes from the ownership cache (e.g. after a deletion).
+// Calling it without hashes clears the whole cache.
+func (p *Pool) ForgetTorrentOwners(hashes ...string) {
+	p.ownersAccess.Lock()
+	defer p.ownersAccess.Unlock()
+	if len(hashes) == 0 {
+		p.owners = make(map[string]string)
+		return
+	}
+	for _, hash := range hashes {
+		delete(p.owners, strings.ToLower(hash))
+	}
+}
+
+// RouteByHashes groups hashes by owning instance and calls action once per instance with its own hashes.
+// Its signature allows to directly use method expressions such as (*Client).StopTorrents.
+// Hashes which can not be found on any instance are reported within the returned *PoolError
+// (under the instance name "") but do not prevent the other actions to be executed.
+func (p *Pool) RouteByHashes(ctx context.Context, hashes []string, action func(c *Client, ctx context.Context, hashes []string) error) error {
+	owners, lookupErr := p.findOwners(ctx, hashes)
+	byInstance := make(map[string][]string, len(p.names))
+	var missing []string
+	for _, hash := range hashes {
+		if name, found := owners[strings.ToLower(hash)]; found {
+			byInstance[name] = append(byInstance[name], hash)
+		} else {
+			missing = append(missing, hash)
+		}
+	}
+	names := make([]string, 0, len(byInstance))
+	for _, name := range p.names {
+		if _, found := byInstance[name]; found {
+			names = append(names, name)
+		}
+	}
+	err := p.do(ctx, names, func(ctx context.Context, name string, client *Client) error {
+		return action(client, ctx, byInstance[name])
+	})
+	if len(missing) == 0 {
+		return err
+	}
+	// merge the not found hashes within the pool error
+	poolErr := &PoolError{Errors: make(map[string]error)}
+	var actionErr *PoolError
+	if errors.As(err, &actionErr) {
+		for name, err := range actionErr.Errors {
+			poolErr.Errors[name] = err
+		}
+	}
+	var lookupPoolErr *PoolError
+	if errors.As(lookupErr, &lookupPoolErr) {
+		for name, err := range lookupPoolErr.Errors {
+			if _, found := poolErr.Errors[name]; !found {
+				poolErr.Errors[name] = fmt.Errorf("looking up hashes failed: %w", err)
+			}
+		}
+	}
+	poolErr.Errors[""] = fmt.Errorf("%s: %w", strings.Join(missing, hashListSeparator), ErrTorrentNotFound)
+	return poolErr
+}
@@ -0,0 +1,161 @@
+package qbtapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	ctx := context.Background()
+
+	// ── fake instances ──────────────────────────────────────
+	var (
+		stoppedAccess sync.Mutex
+		stopped       = make(map[string]string) // instance -> hashes
+	)
+	newInstance := func(name, hash string) *Client {
+		t.Helper()
+		mux := http.NewServeMux()
+		mux.HandleFunc("/api/v2/torrents/info", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+			if hashes := r.URL.Query().Get("hashes"); hashes != "" && !strings.Contains(hashes, hash) {
+				_, _ = w.Write([]byte(`[]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[{"hash":"` + hash + `","name":"` + name + `"}]`))
+		})
+		mux.HandleFunc("/api/v2/transfer/info", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+			_, _ = w.Write([]byte(`{"connection_status":"connected"}`))
+		})
+		mux.HandleFunc("/api/v2/app/webapiVersion", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(contentTypeHeader, contentTypeHeaderTextPlain)
+			_, _ = w.Write([]byte(APIReferenceVersion))
+		})
+		mux.HandleFunc("/api/v2/torrents/stop", func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			stoppedAccess.Lock()
+			stopped[name] = r.PostForm.Get("hashes")
+			stoppedAccess.Unlock()
+		})
+		server := httptest.NewServer(mux)
+		t.Cleanup(server.Close)
+		endpoint, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatalf("parsing test server URL: %v", err)
+		}
+		client, err := New(endpoint, "user", "pass")
+		if err != nil {
+			t.Fatalf("creating client: %v", err)
+		}
+		return client
+	}
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(down.Close)
+	downURL, _ := url.Parse(down.URL)
+	downClient, err := New(downURL, "user", "pass")
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+
+	pool, err := NewPool(map[string]*Client{
+		"alpha": newInstance("alpha", "aaaa"),
+		"beta":  newInstance("beta", "bbbb"),
+		"down":  downClient,
+	})
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+
+	// ── fan out with partial failure ────────────────────────
+	list, err := pool.GetTorrentList(ctx, nil)
+	var poolErr *PoolError
+	if !errors.As(err, &poolErr) {
+		t.Fatalf("expected a *PoolError, got %v", err)
+	}
+	if len(poolErr.Errors) != 1 || poolErr.Errors["down"] == nil {
+		t.Fatalf("expected only the 'down' instance to fail, got %v", poolErr)
+	}
+	var httpErr HTTPError
+	if !errors.As(err, &httpErr) || httpErr != http.StatusInternalServerError {
+		t.Fatalf("expected the instance HTTP error to be reachable, got %v", err)
+	}
+	if len(list) != 2 || list[0].Instance != "alpha" || list[1].Instance != "beta" {
+		t.Fatalf("unexpected merged listing: %+v", list)
+	}
+
+	infos, err := pool.GetGlobalTransferInfo(ctx)
+	if !errors.As(err, &poolErr) {
+		t.Fatalf("expected a *PoolError, got %v", err)
+	}
+	if len(infos) != 2 || infos[1].ConnectionStatus != "connected" {
+		t.Fatalf("unexpected transfer infos: %+v", infos)
+	}
+
+	// ── JSON instance tag ───────────────────────────────────
+	data, err := json.Marshal(list)
+	if err != nil {
+		t.Fatalf("marshaling listing: %v", err)
+	}
+	var decodedList []PoolTorrentInfos
+	if err = json.Unmarshal(data, &decodedList); err != nil {
+		t.Fatalf("unmarshaling listing: %v", err)
+	}
+	if len(decodedList) != 2 || decodedList[1].Instance != "beta" || decodedList[1].Hash != "bbbb" {
+		t.Fatalf("listing round trip lost the instance: %s", data)
+	}
+	if data, err = json.Marshal(infos); err != nil {
+		t.Fatalf("marshaling transfer infos: %v", err)
+	}
+	var decodedInfos []PoolGlobalTransferInfo
+	if err = json.Unmarshal(data, &decodedInfos); err != nil {
+		t.Fatalf("unmarshaling transfer infos: %v", err)
+	}
+	if len(decodedInfos) != 2 || decodedInfos[0].Instance != "alpha" || decodedInfos[0].ConnectionStatus != "connected" {
+		t.Fatalf("transfer infos round trip lost the instance: %s", data)
+	}
+	entry := PoolLogEntry{Instance: "alpha", LogEntry: LogEntry{ID: 7, Message: "started", Timestamp: time.Unix(1700000000, 0)}}
+	if data, err = json.Marshal(entry); err != nil {
+		t.Fatalf("marshaling log entry: %v", err)
+	}
+	var decodedEntry PoolLogEntry
+	if err = json.Unmarshal(data, &decodedEntry); err != nil {
+		t.Fatalf("unmarshaling log entry: %v", err)
+	}
+	if decodedEntry.Instance != "alpha" || decodedEntry.ID != 7 || !decodedEntry.Timestamp.Equal(entry.Timestamp) {
+		t.Fatalf("log entry round trip lost the instance: %s", data)
+	}
+
+	// ── hash routing ────────────────────────────────────────
+	pool.ForgetTorrentOwners()
+	owner, err := pool.FindTorrentOwner(ctx, "BBBB")
+	if err != nil {
+		t.Fatalf("FindTorrentOwner: %v", err)
+	}
+	if owner != "beta" {
+		t.Fatalf("expected owner 'beta', got %q", owner)
+	}
+	if err = pool.RouteByHashes(ctx, []string{"aaaa", "bbbb"}, (*Client).StopTorrents); err != nil {
+		t.Fatalf("RouteByHashes: %v", err)
+	}
+	if stopped["alpha"] != "aaaa" || stopped["beta"] != "bbbb" {
+		t.Fatalf("actions not routed to their owners: %v", stopped)
+	}
+	err = pool.RouteByHashes(ctx, []string{"cccc"}, (*Client).StopTorrents)
+	if !errors.Is(err, ErrTorrentNotFound) {
+		t.Fatalf("expected ErrTorrentNotFound for an unknown hash, got %v", err)
+	}
+}
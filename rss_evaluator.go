@@ -0,0 +1,406 @@
+package qbtapi
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+	Local evaluation of RSS auto-downloading rules
+*/
+
+// DefaultRSSSmartEpisodeFilters are the smart episode filters qBittorrent uses when not customized.
+var DefaultRSSSmartEpisodeFilters = []string{
+	`s(\d+)e(\d+)`,
+	`(\d+)x(\d+)`,
+	`(\d{4}[.\-]\d{1,2}[.\-]\d{1,2})`,
+	`(\d{1,2}[.\-]\d{1,2}[.\-]\d{4})`,
+}
+
+// RSSRuleEvaluatorOptions contains the application wide settings affecting rules evaluation.
+// All fields are optional: nil values use the qBittorrent defaults.
+type RSSRuleEvaluatorOptions struct {
+	SmartEpisodeFilters          []string // Regular expressions extracting the episode identifier of a title
+	DownloadRepackProperEpisodes *bool    // Allow REPACK/PROPER releases of an already downloaded episode
+}
+
+// RSSRuleEvaluatorOptionsFromPreferences extracts the rules evaluation settings of an instance preferences.
+func RSSRuleEvaluatorOptionsFromPreferences(prefs ApplicationPreferences) (options *RSSRuleEvaluatorOptions) {
+	options = &RSSRuleEvaluatorOptions{
+		DownloadRepackProperEpisodes: prefs.RSSDownloadRepackProperEpisodes,
+	}
+	if prefs.RSSSmartEpisodeFilters != nil {
+		for _, filter := range strings.Split(*prefs.RSSSmartEpisodeFilters, "\n") {
+			if filter = strings.TrimSpace(filter); filter != "" {
+				options.SmartEpisodeFilters = append(options.SmartEpisodeFilters, filter)
+			}
+		}
+	}
+	return
+}
+
+// RSSRuleEvaluator applies an auto-downloading rule to articles the same way qBittorrent does,
+// allowing to test rules offline. Note that regular expressions are evaluated with the Go RE2
+// engine: the few PCRE only constructs (lookarounds, backreferences) are not supported.
+// Must be instanciated with NewRSSRuleEvaluator().
+type RSSRuleEvaluator struct {
+	rule              RSSAutoDownloadingRule
+	mustContain       [][]*regexp.Regexp // any expression must match, an expression matching if all its regexes match
+	mustNotContain    [][]*regexp.Regexp // no expression must match
+	smartFilter       *regexp.Regexp
+	downloadRepacks   bool
+	lastMatch         time.Time
+	previouslyMatched map[string]struct{}
+	now               func() time.Time // clock of the accepted matches
+}
+
+// NewRSSRuleEvaluator returns an evaluator for rule. options is optional and can be nil.
+func NewRSSRuleEvaluator(rule RSSAutoDownloadingRule, options *RSSRuleEvaluatorOptions) (ev *RSSRuleEvaluator, err error) {
+	evaluator := &RSSRuleEvaluator{
+		rule:              rule,
+		downloadRepacks:   true,
+		previouslyMatched: make(map[string]struct{}, len(rule.PreviouslyMatchedEpisodes)),
+		now:               time.Now,
+	}
+	if evaluator.mustContain, err = compileRSSRuleExpressions(rule.MustContain, rule.UseRegex); err != nil {
+		err = fmt.Errorf("compiling must contain expressions failed: %w", err)
+		return
+	}
+	if evaluator.mustNotContain, err = compileRSSRuleExpressions(rule.MustNotContain, rule.UseRegex); err != nil {
+		err = fmt.Errorf("compiling must not contain expressions failed: %w", err)
+		return
+	}
+	smartFilters := DefaultRSSSmartEpisodeFilters
+	if options != nil {
+		if options.SmartEpisodeFilters != nil {
+			smartFilters = options.SmartEpisodeFilters
+		}
+		if options.DownloadRepackProperEpisodes != nil {
+			evaluator.downloadRepacks = *options.DownloadRepackProperEpisodes
+		}
+	}
+	if evaluator.smartFilter, err = regexp.Compile(`(?i)(?:_|\b)(?:` + strings.Join(smartFilters, `)|(?:`) + `)(?:_|\b)`); err != nil {
+		err = fmt.Errorf("compiling smart episode filters failed: %w", err)
+		return
+	}
+	// an invalid last match is ignored, as qBittorrent does
+	evaluator.lastMatch, _ = parseRSSDate(rule.LastMatch)
+	for _, episode := range rule.PreviouslyMatchedEpisodes {
+		evaluator.previouslyMatched[episode] = struct{}{}
+	}
+	ev = evaluator
+	return
+}
+
+// compileRSSRuleExpressions compiles must (not) contain tokens: with regexes, tokens is a single expression.
+// Otherwise expressions are separated by '|' and each of them is made of wildcards separated by whitespaces.
+func compileRSSRuleExpressions(tokens string, useRegex bool) (expressions [][]*regexp.Regexp, err error) {
+	if tokens == "" {
+		return
+	}
+	if useRegex {
+		var regex *regexp.Regexp
+		if regex, err = regexp.Compile("(?i)" + tokens); err != nil {
+			return
+		}
+		expressions = [][]*regexp.Regexp{{regex}}
+		return
+	}
+	for _, expression := range strings.Split(tokens, "|") {
+		// an empty expression always matches (as "expr|" would in regex mode)
+		wildcards := strings.Fields(expression)
+		regexes := make([]*regexp.Regexp, len(wildcards))
+		for index, wildcard := range wildcards {
+			if regexes[index], err = regexp.Compile("(?i)" + wildcardToRegexPattern(wildcard)); err != nil {
+				return
+			}
+		}
+		expressions = append(expressions, regexes)
+	}
+	return
+}
+
+// wildcardToRegexPattern converts an unanchored wildcard ('*', '?' and '[...]' classes) to a regex
+// pattern, following Qt 6 rules ('*' and '?' do not match '/').
+func wildcardToRegexPattern(wildcard string) string {
+	var pattern strings.Builder
+	runes := []rune(wildcard)
+	for index := 0; index < len(runes); index++ {
+		switch runes[index] {
+		case '*':
+			pattern.WriteString(`[^/]*`)
+		case '?':
+			pattern.WriteString(`[^/]`)
+		case '[':
+			end := index + 1
+			if end < len(runes) && (runes[end] == '!' || runes[end] == '^') {
+				end++
+			}
+			if end < len(runes) && runes[end] == ']' {
+				end++
+			}
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end >= len(runes) {
+				// unterminated class: literal '['
+				pattern.WriteString(`\[`)
+				continue
+			}
+			class := runes[index+1 : end]
+			pattern.WriteByte('[')
+			if len(class) > 0 && class[0] == '!' {
+				pattern.WriteByte('^')
+				class = class[1:]
+			}
+			pattern.WriteString(strings.ReplaceAll(string(class), `\`, `\\`))
+			pattern.WriteByte(']')
+			index = end
+		default:
+			pattern.WriteString(regexp.QuoteMeta(string(runes[index])))
+		}
+	}
+	return pattern.String()
+}
+
+func matchesRSSRuleExpression(title string, regexes []*regexp.Regexp) bool {
+	for _, regex := range regexes {
+		if !regex.MatchString(title) {
+			return false
+		}
+	}
+	return true
+}
+
+// RSSRuleEvaluation is the result of the evaluation of an article.
+type RSSRuleEvaluation struct {
+	Article  RSSArticle
+	Matched  bool
+	Reason   string   // Why the article did or did not match
+	Episodes []string // Episode identifiers recorded as matched by the smart filter (only if Matched)
+}
+
+// Match evaluates article without altering the evaluator state (last match and previously matched episodes).
+func (ev *RSSRuleEvaluator) Match(article RSSArticle) (evaluation RSSRuleEvaluation) {
+	evaluation.Article = article
+	if !ev.rule.Enabled {
+		evaluation.Reason = "rule is disabled"
+		return
+	}
+	if ev.rule.IgnoreDays > 0 && !ev.lastMatch.IsZero() {
+		if until := ev.lastMatch.AddDate(0, 0, ev.rule.IgnoreDays); article.Date.Before(until) {
+			evaluation.Reason = fmt.Sprintf("ignored: article published before %s (last match %s + %d day(s))",
+				until.Format(time.RFC1123Z), ev.lastMatch.Format(time.RFC1123Z), ev.rule.IgnoreDays)
+			return
+		}
+	}
+	if len(ev.mustContain) > 0 {
+		matched := false
+		for _, expression := range ev.mustContain {
+			if matchesRSSRuleExpression(article.Title, expression) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			evaluation.Reason = fmt.Sprintf("title does not match must contain expression %q", ev.rule.MustContain)
+			return
+		}
+	}
+	for index, expression := range ev.mustNotContain {
+		if matchesRSSRuleExpression(article.Title, expression) {
+			part := ev.rule.MustNotContain
+			if !ev.rule.UseRegex {
+				part = strings.Split(part, "|")[index]
+			}
+			evaluation.Reason = fmt.Sprintf("title matches must not contain expression %q", part)
+			return
+		}
+	}
+	if ev.rule.EpisodeFilter != "" && !matchesRSSEpisodeFilter(article.Title, ev.rule.EpisodeFilter) {
+		evaluation.Reason = fmt.Sprintf("title does not match episode filter %q", ev.rule.EpisodeFilter)
+		return
+	}
+	var reason string
+	if ev.rule.SmartFilter {
+		if evaluation.Episodes, reason = ev.matchSmartFilter(article.Title); evaluation.Episodes == nil && reason != "" {
+			evaluation.Reason = reason
+			return
+		}
+	}
+	evaluation.Matched = true
+	if reason == "" {
+		reason = "all the rule conditions are met"
+	}
+	evaluation.Reason = reason
+	return
+}
+
+// Accept evaluates article and, if it matches, records it as qBittorrent would when downloading it:
+// the last match date is set to the current time (not the article date) and its episode is added to the previously matched episodes.
+func (ev *RSSRuleEvaluator) Accept(article RSSArticle) (evaluation RSSRuleEvaluation) {
+	if evaluation = ev.Match(article); !evaluation.Matched {
+		return
+	}
+	ev.lastMatch = ev.now()
+	for _, episode := range evaluation.Episodes {
+		ev.previouslyMatched[episode] = struct{}{}
+	}
+	return
+}
+
+// Evaluate accepts articles in order (see Accept()) and returns their evaluations.
+func (ev *RSSRuleEvaluator) Evaluate(articles []RSSArticle) (evaluations []RSSRuleEvaluation) {
+	evaluations = make([]RSSRuleEvaluation, len(articles))
+	for index, article := range articles {
+		evaluations[index] = ev.Accept(article)
+	}
+	return
+}
+
+// EvaluateTitles is a convenience wrapper around Evaluate() for undated articles identified by their title only.
+func (ev *RSSRuleEvaluator) EvaluateTitles(titles ...string) (evaluations []RSSRuleEvaluation) {
+	articles := make([]RSSArticle, len(titles))
+	for index, title := range titles {
+		articles[index].Title = title
+	}
+	return ev.Evaluate(articles)
+}
+
+// Rule returns the evaluated rule with its LastMatch and PreviouslyMatchedEpisodes updated by the accepted articles.
+func (ev *RSSRuleEvaluator) Rule() (rule RSSAutoDownloadingRule) {
+	rule = ev.rule
+	if !ev.lastMatch.IsZero() {
+		rule.LastMatch = ev.lastMatch.Format(time.RFC1123Z)
+	}
+	rule.PreviouslyMatchedEpisodes = sortedKeys(ev.previouslyMatched)
+	return
+}
+
+var (
+	rssEpisodeFilterRegex       = regexp.MustCompile(`(?i)(^\d{1,4})x(.*;$)`)
+	rssEpisodeRangeSERegex      = regexp.MustCompile(`(?i)\bs0?(\d{1,4})[ -_\.]?e(0?\d{1,4})(?:\D|\b)`)
+	rssEpisodeRangeSeasonXRegex = regexp.MustCompile(`(?i)\b(\d{1,4})x(0?\d{1,4})(?:\D|\b)`)
+)
+
+// matchesRSSEpisodeFilter applies an episode filter such as "1x2-5;2x-;" (season 1, single episodes,
+// ranges and infinite ranges separated by ';', the filter ending with ';') to a title.
+func matchesRSSEpisodeFilter(title, filter string) bool {
+	matches := rssEpisodeFilterRegex.FindStringSubmatch(filter)
+	if matches == nil {
+		return false
+	}
+	season := matches[1]
+	seasonOurs, _ := strconv.Atoi(season)
+	for _, episode := range strings.Split(matches[2], ";") {
+		if episode == "" {
+			continue
+		}
+		// trim leading zeroes, keeping a single one for episode zero
+		for len(episode) > 1 && episode[0] == '0' {
+			episode = episode[1:]
+		}
+		if !strings.Contains(episode, "-") {
+			// single episode
+			regex, err := regexp.Compile(fmt.Sprintf(`(?i)\b(?:s0?%[1]s[ -_\.]?e0?%[2]s|%[1]sx0?%[2]s)(?:\D|\b)`, season, episode))
+			if err == nil && regex.MatchString(title) {
+				return true
+			}
+			continue
+		}
+		// range: extract the season and episode of the title
+		theirs := rssEpisodeRangeSERegex.FindStringSubmatch(title)
+		if theirs == nil {
+			if theirs = rssEpisodeRangeSeasonXRegex.FindStringSubmatch(title); theirs == nil {
+				continue
+			}
+		}
+		seasonTheirs, _ := strconv.Atoi(theirs[1])
+		episodeTheirs, _ := strconv.Atoi(theirs[2])
+		if strings.HasSuffix(episode, "-") {
+			// infinite range
+			episodeOurs, _ := strconv.Atoi(strings.TrimSuffix(episode, "-"))
+			if (seasonTheirs == seasonOurs && episodeTheirs >= episodeOurs) || seasonTheirs > seasonOurs {
+				return true
+			}
+			continue
+		}
+		first, last, _ := strings.Cut(episode, "-")
+		episodeOursFirst, _ := strconv.Atoi(first)
+		episodeOursLast, _ := strconv.Atoi(last)
+		if episodeOursFirst > episodeOursLast {
+			// invalid range, ignored
+			continue
+		}
+		if seasonTheirs == seasonOurs && episodeOursFirst <= episodeTheirs && episodeTheirs <= episodeOursLast {
+			return true
+		}
+	}
+	return false
+}
+
+// episodeName extracts the episode identifier of a title (e.g. "1x2") with the smart episode filters.
+func (ev *RSSRuleEvaluator) episodeName(title string) string {
+	matches := ev.smartFilter.FindStringSubmatch(title)
+	if matches == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(matches)-1)
+	for _, capture := range matches[1:] {
+		if capture == "" {
+			continue
+		}
+		if number, err := strconv.Atoi(capture); err == nil {
+			capture = strconv.Itoa(number)
+		}
+		parts = append(parts, capture)
+	}
+	return strings.Join(parts, "x")
+}
+
+// matchSmartFilter returns the episodes to record if title passes the smart filter.
+// If it does not, episodes is nil and reason explains why.
+func (ev *RSSRuleEvaluator) matchSmartFilter(title string) (episodes []string, reason string) {
+	episode := ev.episodeName(title)
+	if episode == "" {
+		// no episode identifier: smart filter does not apply
+		return
+	}
+	if _, found := ev.previouslyMatched[episode]; !found {
+		episodes = []string{episode}
+		reason = fmt.Sprintf("episode %s not downloaded yet", episode)
+		return
+	}
+	if !ev.downloadRepacks {
+		reason = fmt.Sprintf("episode %s already downloaded", episode)
+		return
+	}
+	upperTitle := strings.ToUpper(title)
+	isRepack := strings.Contains(upperTitle, "REPACK")
+	isProper := strings.Contains(upperTitle, "PROPER")
+	if !isRepack && !isProper {
+		reason = fmt.Sprintf("episode %s already downloaded", episode)
+		return
+	}
+	fullEpisode := episode
+	if isRepack {
+		fullEpisode += "-REPACK"
+	}
+	if isProper {
+		fullEpisode += "-PROPER"
+	}
+	if _, found := ev.previouslyMatched[fullEpisode]; found {
+		reason = fmt.Sprintf("episode %s already downloaded", fullEpisode)
+		return
+	}
+	episodes = []string{fullEpisode}
+	if isRepack && isProper {
+		episodes = append(episodes, episode+"-REPACK", episode+"-PROPER")
+	}
+	episodes = append(episodes, episode)
+	reason = fmt.Sprintf("episode %s not downloaded yet", fullEpisode)
+	return
+}
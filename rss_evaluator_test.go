@@ -0,0 +1,109 @@
+package qbtapi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRSSRuleEvaluator(t *testing.T) {
+	evaluate := func(t *testing.T, rule RSSAutoDownloadingRule, titles ...string) []RSSRuleEvaluation {
+		t.Helper()
+		rule.Enabled = true
+		evaluator, err := NewRSSRuleEvaluator(rule, nil)
+		if err != nil {
+			t.Fatalf("NewRSSRuleEvaluator: %v", err)
+		}
+		return evaluator.EvaluateTitles(titles...)
+	}
+	expect := func(t *testing.T, evaluations []RSSRuleEvaluation, matched ...bool) {
+		t.Helper()
+		for index, evaluation := range evaluations {
+			if evaluation.Matched != matched[index] {
+				t.Fatalf("%q: expected matched=%v, got %v (%s)",
+					evaluation.Article.Title, matched[index], evaluation.Matched, evaluation.Reason)
+			}
+		}
+	}
+
+	// ── must (not) contain ──────────────────────────────────
+	expect(t, evaluate(t, RSSAutoDownloadingRule{
+		MustContain:    "show 1080p|other*show",
+		MustNotContain: "x265|cam",
+	},
+		"The.Show.S01E01.1080p.WEB",     // all wildcards of the first expression
+		"The.Show.S01E01.720p.WEB",      // missing 1080p
+		"Another.Show.S01E01.720p",      // second expression
+		"The.Show.S01E02.1080p.x265",    // must not contain
+		"The.Other.Movie.CAM.Show.720p", // must not contain, case insensitive
+	), true, false, true, false, false)
+	expect(t, evaluate(t, RSSAutoDownloadingRule{
+		MustContain: `^The\.Show\.S\d+E\d+`,
+		UseRegex:    true,
+	}, "The.Show.S01E01", "Not.The.Show.S01E01"), true, false)
+
+	// ── episode filter ──────────────────────────────────────
+	expect(t, evaluate(t, RSSAutoDownloadingRule{
+		EpisodeFilter: "1x2-4;07;10-;",
+	},
+		"Show S01E01 1080p", // before range
+		"Show S01E03 1080p", // in range
+		"Show 1x07 1080p",   // single episode, leading zero trimmed
+		"Show S01E08 1080p", // not listed
+		"Show S01E12 1080p", // infinite range
+		"Show S02E01 1080p", // infinite range, later season
+	), false, true, true, false, true, true)
+	expect(t, evaluate(t, RSSAutoDownloadingRule{EpisodeFilter: "1x2"}, "Show S01E02"), false)
+
+	// ── smart filter ────────────────────────────────────────
+	expect(t, evaluate(t, RSSAutoDownloadingRule{SmartFilter: true},
+		"Show S01E02 720p",
+		"Show S01E02 1080p",        // same episode
+		"Show S01E02 REPACK 1080p", // repack of a downloaded episode
+		"Show S01E02 REPACK 720p",  // repack already downloaded
+		"Show 2024.10.08 1080p",    // dated episode
+		"Show 08.10.2024 720p",     // same date, other notation is another identifier
+		"Show without episode",     // smart filter does not apply
+		"Show without episode",
+	), true, false, true, false, true, true, true, true)
+
+	noRepacks := false
+	evaluator, err := NewRSSRuleEvaluator(RSSAutoDownloadingRule{
+		Enabled:                   true,
+		SmartFilter:               true,
+		PreviouslyMatchedEpisodes: []string{"1x2"},
+	}, &RSSRuleEvaluatorOptions{DownloadRepackProperEpisodes: &noRepacks})
+	if err != nil {
+		t.Fatalf("NewRSSRuleEvaluator: %v", err)
+	}
+	expect(t, evaluator.EvaluateTitles("Show S01E02 PROPER", "Show S01E03"), false, true)
+	if rule := evaluator.Rule(); len(rule.PreviouslyMatchedEpisodes) != 2 || rule.PreviouslyMatchedEpisodes[1] != "1x3" {
+		t.Fatalf("matched episodes not recorded: %v", rule.PreviouslyMatchedEpisodes)
+	}
+
+	// ── ignore days ─────────────────────────────────────────
+	lastMatch := time.Date(2024, time.October, 1, 12, 0, 0, 0, time.UTC)
+	evaluator, err = NewRSSRuleEvaluator(RSSAutoDownloadingRule{
+		Enabled:    true,
+		IgnoreDays: 7,
+		LastMatch:  lastMatch.Format(time.RFC1123Z),
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewRSSRuleEvaluator: %v", err)
+	}
+	accepted := lastMatch.AddDate(0, 0, 9)
+	evaluator.now = func() time.Time { return accepted }
+	expect(t, evaluator.Evaluate([]RSSArticle{
+		{Title: "too soon", Date: lastMatch.AddDate(0, 0, 3)},
+		{Title: "late enough", Date: lastMatch.AddDate(0, 0, 8)},
+		{Title: "too soon after the new match", Date: lastMatch.AddDate(0, 0, 10)},
+	}), false, true, false)
+	if rule := evaluator.Rule(); rule.LastMatch != accepted.Format(time.RFC1123Z) {
+		t.Fatalf("last match not set to the acceptance time: %s", rule.LastMatch)
+	}
+
+	// ── disabled rule ───────────────────────────────────────
+	if evaluator, err = NewRSSRuleEvaluator(RSSAutoDownloadingRule{}, nil); err != nil {
+		t.Fatalf("NewRSSRuleEvaluator: %v", err)
+	}
+	expect(t, evaluator.EvaluateTitles("anything"), false)
+}
@@ -0,0 +1,225 @@
+package qbtapi
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+	RSS feeds OPML import/export
+*/
+
+type opmlDocument struct {
+	XMLName xml.Name      `xml:"opml"`
+	Version string        `xml:"version,attr"`
+	Title   string        `xml:"head>title,omitempty"`
+	Body    []opmlOutline `xml:"body>outline"`
+}
+
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// WriteRSSItemsOPML writes the folders and feeds of root as an OPML 2.0 document, folders being
+// represented by nested outlines. Items are sorted by name (folders first).
+func WriteRSSItemsOPML(w io.Writer, root RSSFolder, title string) (err error) {
+	document := opmlDocument{
+		Version: "2.0",
+		Title:   title,
+		Body:    opmlOutlines(&root),
+	}
+	if _, err = io.WriteString(w, xml.Header); err != nil {
+		err = fmt.Errorf("writing OPML header failed: %w", err)
+		return
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err = encoder.Encode(document); err != nil {
+		err = fmt.Errorf("encoding OPML failed: %w", err)
+		return
+	}
+	if _, err = io.WriteString(w, "\n"); err != nil {
+		err = fmt.Errorf("writing OPML failed: %w", err)
+	}
+	return
+}
+
+func opmlOutlines(folder *RSSFolder) (outlines []opmlOutline) {
+	for _, name := range sortedKeys(folder.Folders) {
+		outlines = append(outlines, opmlOutline{
+			Text:     name,
+			Title:    name,
+			Outlines: opmlOutlines(folder.Folders[name]),
+		})
+	}
+	for _, name := range sortedKeys(folder.Feeds) {
+		outlines = append(outlines, opmlOutline{
+			Text:   name,
+			Title:  name,
+			Type:   "rss",
+			XMLURL: folder.Feeds[name].URL,
+		})
+	}
+	return
+}
+
+// ReadRSSItemsOPML reads an OPML document and returns its hierarchy as a RSS tree (feeds only have their URL set).
+// Outlines with a xmlUrl attribute are feeds, other outlines are folders. Item names are taken from the text
+// attribute (or title, or URL if both are empty) with the RSSPathSeparator character replaced by '/'.
+func ReadRSSItemsOPML(r io.Reader) (root RSSFolder, err error) {
+	var document opmlDocument
+	if err = xml.NewDecoder(r).Decode(&document); err != nil {
+		err = fmt.Errorf("decoding OPML failed: %w", err)
+		return
+	}
+	root = rssFolderFromOPML(document.Body)
+	return
+}
+
+func rssFolderFromOPML(outlines []opmlOutline) (folder RSSFolder) {
+	folder.Folders = make(map[string]*RSSFolder)
+	folder.Feeds = make(map[string]*RSSFeed)
+	for _, outline := range outlines {
+		name := outline.Text
+		if name == "" {
+			name = outline.Title
+		}
+		if name == "" {
+			name = outline.XMLURL
+		}
+		name = strings.ReplaceAll(strings.TrimSpace(name), RSSPathSeparator, "/")
+		if outline.XMLURL != "" {
+			folder.Feeds[name] = &RSSFeed{URL: outline.XMLURL}
+			continue
+		}
+		subFolder := rssFolderFromOPML(outline.Outlines)
+		if existing, found := folder.Folders[name]; found {
+			// same folder listed twice: merge them
+			mergeRSSFolders(existing, &subFolder)
+			continue
+		}
+		folder.Folders[name] = &subFolder
+	}
+	return
+}
+
+// mergeRSSFolders recursively merges the folders and feeds of from into into, feeds of from taking precedence.
+func mergeRSSFolders(into, from *RSSFolder) {
+	for name, sub := range from.Folders {
+		if existing, found := into.Folders[name]; found {
+			mergeRSSFolders(existing, sub)
+			continue
+		}
+		into.Folders[name] = sub
+	}
+	for name, feed := range from.Feeds {
+		into.Feeds[name] = feed
+	}
+}
+
+// ExportRSSItemsOPML writes the RSS folders and feeds of the instance to w. See WriteRSSItemsOPML().
+func (c *Client) ExportRSSItemsOPML(ctx context.Context, w io.Writer, title string) (err error) {
+	root, err := c.GetAllRSSItems(ctx, nil)
+	if err != nil {
+		err = fmt.Errorf("getting RSS items failed: %w", err)
+		return
+	}
+	return WriteRSSItemsOPML(w, root, title)
+}
+
+// RSSOPMLImport reports the outcome of ImportRSSItemsOPML().
+type RSSOPMLImport struct {
+	CreatedFolders []string          // Paths of the created folders
+	CreatedFeeds   []string          // Paths of the created feeds
+	SkippedFeeds   map[string]string // Paths (within the OPML document) of the feeds skipped -> path of the existing feed with the same URL
+}
+
+// ImportRSSItemsOPML reads an OPML document from r and creates its folders and feeds on the instance, under the
+// destPath folder (empty for the root folder, which must exist otherwise). Nesting is preserved, existing folders are
+// reused and feeds whose URL already exists anywhere on the instance are skipped. If a different feed already uses
+// the name of an imported one, the imported feed is renamed ("name (2)", etc...).
+// Items created before an error are reported alongside it.
+func (c *Client) ImportRSSItemsOPML(ctx context.Context, r io.Reader, destPath string) (report RSSOPMLImport, err error) {
+	imported, err := ReadRSSItemsOPML(r)
+	if err != nil {
+		return
+	}
+	existing, err := c.GetAllRSSItems(ctx, nil)
+	if err != nil {
+		err = fmt.Errorf("getting RSS items failed: %w", err)
+		return
+	}
+	destination := existing.Folder(destPath)
+	if destination == nil {
+		err = fmt.Errorf("destination folder %q does not exist", destPath)
+		return
+	}
+	// index existing feeds by URL
+	existingURLs := make(map[string]string)
+	_ = existing.Walk(func(path string, item RSSItem) error {
+		if feed, isFeed := item.(*RSSFeed); isFeed {
+			existingURLs[feed.URL] = path
+		}
+		return nil
+	})
+	prefix := ""
+	if destPath != "" {
+		prefix = destPath + RSSPathSeparator
+	}
+	report.SkippedFeeds = make(map[string]string)
+	err = c.importRSSFolder(ctx, &imported, "", destination, prefix, existingURLs, &report)
+	return
+}
+
+func (c *Client) importRSSFolder(ctx context.Context, imported *RSSFolder, importedPrefix string, destination *RSSFolder, prefix string, existingURLs map[string]string, report *RSSOPMLImport) (err error) {
+	for _, name := range sortedKeys(imported.Folders) {
+		subDestination := destination.Folders[name]
+		if subDestination == nil {
+			if _, isFeed := destination.Feeds[name]; isFeed {
+				err = fmt.Errorf("folder %q can not be created: a feed already has this path", prefix+name)
+				return
+			}
+			if err = c.AddRSSFolder(ctx, prefix+name); err != nil {
+				err = fmt.Errorf("adding folder %q failed: %w", prefix+name, err)
+				return
+			}
+			report.CreatedFolders = append(report.CreatedFolders, prefix+name)
+			subDestination = &RSSFolder{
+				Folders: make(map[string]*RSSFolder),
+				Feeds:   make(map[string]*RSSFeed),
+			}
+			destination.Folders[name] = subDestination
+		}
+		if err = c.importRSSFolder(ctx, imported.Folders[name], importedPrefix+name+RSSPathSeparator,
+			subDestination, prefix+name+RSSPathSeparator, existingURLs, report); err != nil {
+			return
+		}
+	}
+	for _, name := range sortedKeys(imported.Feeds) {
+		feed := imported.Feeds[name]
+		if existingPath, found := existingURLs[feed.URL]; found {
+			report.SkippedFeeds[importedPrefix+name] = existingPath
+			continue
+		}
+		feedName := name
+		for suffix := 2; destination.Item(feedName) != nil; suffix++ {
+			feedName = fmt.Sprintf("%s (%d)", name, suffix)
+		}
+		path := prefix + feedName
+		if err = c.AddRSSFeed(ctx, feed.URL, &path); err != nil {
+			err = fmt.Errorf("adding feed %q failed: %w", path, err)
+			return
+		}
+		report.CreatedFeeds = append(report.CreatedFeeds, path)
+		destination.Feeds[feedName] = &RSSFeed{URL: feed.URL}
+		existingURLs[feed.URL] = path
+	}
+	return
+}
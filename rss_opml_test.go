@@ -0,0 +1,111 @@
+package qbtapi
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRSSItemsOPML(t *testing.T) {
+	ctx := context.Background()
+
+	// ── write and read back ─────────────────────────────────
+	root := RSSFolder{
+		Folders: map[string]*RSSFolder{
+			"linux": {
+				Folders: map[string]*RSSFolder{},
+				Feeds:   map[string]*RSSFeed{"ubuntu": {URL: "https://example.com/ubuntu.rss?a=1&b=2"}},
+			},
+		},
+		Feeds: map[string]*RSSFeed{"news": {URL: "https://example.com/news.rss"}},
+	}
+	var document bytes.Buffer
+	if err := WriteRSSItemsOPML(&document, root, "feeds"); err != nil {
+		t.Fatalf("WriteRSSItemsOPML: %v", err)
+	}
+	read, err := ReadRSSItemsOPML(bytes.NewReader(document.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadRSSItemsOPML: %v", err)
+	}
+	if feed := read.Feed(`linux\ubuntu`); feed == nil || feed.URL != root.Folders["linux"].Feeds["ubuntu"].URL {
+		t.Fatalf("nested feed not read back:\n%s", document.String())
+	}
+	if read.Feed("news") == nil {
+		t.Fatalf("root feed not read back:\n%s", document.String())
+	}
+
+	// ── duplicate folders are merged recursively ────────────
+	read, err = ReadRSSItemsOPML(strings.NewReader(`<?xml version="1.0"?>
+<opml version="2.0">
+  <body>
+    <outline text="linux">
+      <outline text="distros">
+        <outline text="debian" xmlUrl="https://example.com/debian.rss"/>
+      </outline>
+    </outline>
+    <outline text="linux">
+      <outline text="distros">
+        <outline text="arch" xmlUrl="https://example.com/arch.rss"/>
+      </outline>
+    </outline>
+  </body>
+</opml>`))
+	if err != nil {
+		t.Fatalf("ReadRSSItemsOPML: %v", err)
+	}
+	if read.Feed(`linux\distros\debian`) == nil || read.Feed(`linux\distros\arch`) == nil {
+		t.Fatalf("duplicate folders not merged recursively: %+v", read.Folder(`linux\distros`))
+	}
+
+	// ── import on a fake instance ───────────────────────────
+	var created []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/rss/items", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		_, _ = w.Write([]byte(`{"linux": {"debian": {"uid": "1", "url": "https://example.com/debian.rss"}}, "ubuntu": {"uid": "2", "url": "https://example.com/other.rss"}}`))
+	})
+	for _, method := range []string{"addFolder", "addFeed"} {
+		mux.HandleFunc("/api/v2/rss/"+method, func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			created = append(created, method+":"+r.PostForm.Get("path"))
+		})
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	endpoint, _ := url.Parse(server.URL)
+	client, err := New(endpoint, "user", "pass")
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	report, err := client.ImportRSSItemsOPML(ctx, strings.NewReader(`<?xml version="1.0"?>
+<opml version="2.0">
+  <head><title>reader export</title></head>
+  <body>
+    <outline text="linux">
+      <outline text="debian" type="rss" xmlUrl="https://example.com/debian.rss"/>
+      <outline text="ubuntu" type="rss" xmlUrl="https://example.com/ubuntu.rss"/>
+      <outline text="bsd">
+        <outline title="freebsd" xmlUrl="https://example.com/freebsd.rss"/>
+      </outline>
+    </outline>
+    <outline text="ubuntu" xmlUrl="https://example.com/ubuntu-news.rss"/>
+  </body>
+</opml>`), "")
+	if err != nil {
+		t.Fatalf("ImportRSSItemsOPML: %v", err)
+	}
+	expected := []string{`addFolder:linux\bsd`, `addFeed:linux\bsd\freebsd`, `addFeed:linux\ubuntu`, `addFeed:ubuntu (2)`}
+	if strings.Join(created, " ") != strings.Join(expected, " ") {
+		t.Fatalf("unexpected creations: expected %v, got %v", expected, created)
+	}
+	if len(report.CreatedFolders) != 1 || len(report.CreatedFeeds) != 3 || report.SkippedFeeds[`linux\debian`] != `linux\debian` {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
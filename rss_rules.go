@@ -0,0 +1,166 @@
+package qbtapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+/*
+	RSS auto-downloading rules import/export
+*/
+
+// WriteRSSAutoDownloadingRules writes rules in the qBittorrent rules export format (the JSON file produced
+// by the "Export..." action of the RSS downloader): a JSON object of rule definitions indexed by rule name.
+// Rules are sorted by name and indented, making the output suitable for versioning and review.
+func WriteRSSAutoDownloadingRules(w io.Writer, rules map[string]RSSAutoDownloadingRule) (err error) {
+	data, err := json.MarshalIndent(rules, "", "    ")
+	if err != nil {
+		err = fmt.Errorf("marshaling rules failed: %w", err)
+		return
+	}
+	if _, err = w.Write(append(data, '\n')); err != nil {
+		err = fmt.Errorf("writing rules failed: %w", err)
+	}
+	return
+}
+
+// ReadRSSAutoDownloadingRules reads rules in the qBittorrent rules export format. See WriteRSSAutoDownloadingRules().
+// Note that the legacy binary format (.dat) of qBittorrent 3 is not supported.
+func ReadRSSAutoDownloadingRules(r io.Reader) (rules map[string]RSSAutoDownloadingRule, err error) {
+	if err = json.NewDecoder(r).Decode(&rules); err != nil {
+		err = fmt.Errorf("decoding rules failed: %w", err)
+	}
+	return
+}
+
+// ExportRSSAutoDownloadingRules writes all the auto-downloading rules of the instance to w.
+// See WriteRSSAutoDownloadingRules() for the format.
+func (c *Client) ExportRSSAutoDownloadingRules(ctx context.Context, w io.Writer) (err error) {
+	rules, err := c.GetAllRSSAutoDownloadingRules(ctx)
+	if err != nil {
+		err = fmt.Errorf("getting rules failed: %w", err)
+		return
+	}
+	return WriteRSSAutoDownloadingRules(w, rules)
+}
+
+// RSSRuleConflictPolicy defines what happens when an imported rule has the name of an existing rule.
+type RSSRuleConflictPolicy uint8
+
+const (
+	RSSRuleConflictSkip      RSSRuleConflictPolicy = iota // Keep the existing rule, do not import
+	RSSRuleConflictOverwrite                              // Replace the existing rule
+	RSSRuleConflictRename                                 // Import the rule under a new name ("name (2)", "name (3)", etc...)
+)
+
+func (rrcp RSSRuleConflictPolicy) String() string {
+	switch rrcp {
+	case RSSRuleConflictSkip:
+		return "Skip"
+	case RSSRuleConflictOverwrite:
+		return "Overwrite"
+	case RSSRuleConflictRename:
+		return "Rename"
+	default:
+		return "Unknown"
+	}
+}
+
+// RSSRulesImportOptions holds the options of ImportRSSAutoDownloadingRules().
+type RSSRulesImportOptions struct {
+	ConflictPolicy RSSRuleConflictPolicy // Default is RSSRuleConflictSkip
+	FeedURLs       map[string]string     // Replaces the feed URLs (keys) of the rules AffectedFeeds by new ones (values)
+	DryRun         bool                  // Only compute the import results
+}
+
+// RSSRuleImport is the outcome of the import of a single rule.
+type RSSRuleImport struct {
+	Name       string                 // Name of the rule within the imported file
+	ImportedAs string                 // Name of the rule on the instance, empty if skipped
+	Conflict   bool                   // True if a rule with the same name already existed
+	Rule       RSSAutoDownloadingRule // Rule as imported (feed URLs remapped)
+}
+
+// ImportRSSAutoDownloadingRules reads rules in the qBittorrent rules export format from r and creates them on
+// the instance. options can be nil. Rules are imported in name order. Imports done before an error are returned
+// alongside it.
+func (c *Client) ImportRSSAutoDownloadingRules(ctx context.Context, r io.Reader, options *RSSRulesImportOptions) (imports []RSSRuleImport, err error) {
+	rules, err := ReadRSSAutoDownloadingRules(r)
+	if err != nil {
+		return
+	}
+	existing, err := c.GetAllRSSAutoDownloadingRules(ctx)
+	if err != nil {
+		err = fmt.Errorf("getting existing rules failed: %w", err)
+		return
+	}
+	if options == nil {
+		options = &RSSRulesImportOptions{}
+	}
+	planned := planRSSRulesImport(existing, rules, options)
+	if options.DryRun {
+		imports = planned
+		return
+	}
+	for _, ruleImport := range planned {
+		if ruleImport.ImportedAs != "" {
+			if err = c.SetRSSAutoDownloadingRule(ctx, ruleImport.ImportedAs, ruleImport.Rule); err != nil {
+				err = fmt.Errorf("setting rule %q failed: %w", ruleImport.ImportedAs, err)
+				return
+			}
+		}
+		imports = append(imports, ruleImport)
+	}
+	return
+}
+
+func planRSSRulesImport(existing, rules map[string]RSSAutoDownloadingRule, options *RSSRulesImportOptions) (imports []RSSRuleImport) {
+	// renamed rules must not collide with existing nor imported names
+	taken := make(map[string]struct{}, len(existing)+len(rules))
+	for name := range existing {
+		taken[name] = struct{}{}
+	}
+	for name := range rules {
+		taken[name] = struct{}{}
+	}
+	imports = make([]RSSRuleImport, 0, len(rules))
+	for _, name := range sortedKeys(rules) {
+		rule := rules[name]
+		if len(options.FeedURLs) > 0 && rule.AffectedFeeds != nil {
+			feeds := make([]string, len(rule.AffectedFeeds))
+			for index, feedURL := range rule.AffectedFeeds {
+				if newURL, found := options.FeedURLs[feedURL]; found {
+					feedURL = newURL
+				}
+				feeds[index] = feedURL
+			}
+			rule.AffectedFeeds = feeds
+		}
+		ruleImport := RSSRuleImport{
+			Name:       name,
+			ImportedAs: name,
+			Rule:       rule,
+		}
+		if _, ruleImport.Conflict = existing[name]; ruleImport.Conflict {
+			switch options.ConflictPolicy {
+			case RSSRuleConflictOverwrite:
+				// keep the name, replacing the existing rule
+			case RSSRuleConflictRename:
+				for suffix := 2; ; suffix++ {
+					candidate := fmt.Sprintf("%s (%d)", name, suffix)
+					if _, found := taken[candidate]; !found {
+						ruleImport.ImportedAs = candidate
+						break
+					}
+				}
+			default:
+				ruleImport.ImportedAs = ""
+			}
+		}
+		taken[ruleImport.ImportedAs] = struct{}{}
+		imports = append(imports, ruleImport)
+	}
+	return
+}
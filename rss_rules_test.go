@@ -0,0 +1,77 @@
+package qbtapi
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRSSRulesImportExport(t *testing.T) {
+	rules := map[string]RSSAutoDownloadingRule{
+		"show": {
+			Enabled:       true,
+			MustContain:   "show 1080p",
+			AffectedFeeds: []string{"https://tracker.example.com/rss?passkey=old", "https://other.example.com/rss"},
+		},
+		"movies": {
+			Enabled:       true,
+			AffectedFeeds: []string{"https://other.example.com/rss"},
+			TorrentParams: NewRSSTorrentParams(),
+		},
+		"show (2)": {
+			MustContain: "show 720p",
+		},
+	}
+
+	// ── write and read back ─────────────────────────────────
+	var file bytes.Buffer
+	if err := WriteRSSAutoDownloadingRules(&file, rules); err != nil {
+		t.Fatalf("WriteRSSAutoDownloadingRules: %v", err)
+	}
+	read, err := ReadRSSAutoDownloadingRules(&file)
+	if err != nil {
+		t.Fatalf("ReadRSSAutoDownloadingRules: %v", err)
+	}
+	if len(read) != 3 || read["movies"].TorrentParams == nil || read["movies"].TorrentParams.UploadLimit == nil || !read["movies"].TorrentParams.UploadLimit.Unlimited() {
+		t.Fatalf("unexpected rules read back: %+v", read)
+	}
+
+	// ── conflict policies ───────────────────────────────────
+	existing := map[string]RSSAutoDownloadingRule{"show": {}}
+	expectImportedAs := func(imports []RSSRuleImport, expected map[string]string) {
+		t.Helper()
+		if len(imports) != len(expected) {
+			t.Fatalf("expected %d imports, got %+v", len(expected), imports)
+		}
+		for _, ruleImport := range imports {
+			if ruleImport.ImportedAs != expected[ruleImport.Name] {
+				t.Fatalf("rule %q: expected to be imported as %q, got %q",
+					ruleImport.Name, expected[ruleImport.Name], ruleImport.ImportedAs)
+			}
+		}
+	}
+	expectImportedAs(planRSSRulesImport(existing, read, &RSSRulesImportOptions{}),
+		map[string]string{"movies": "movies", "show": "", "show (2)": "show (2)"})
+	expectImportedAs(planRSSRulesImport(existing, read, &RSSRulesImportOptions{ConflictPolicy: RSSRuleConflictOverwrite}),
+		map[string]string{"movies": "movies", "show": "show", "show (2)": "show (2)"})
+	imports := planRSSRulesImport(existing, read, &RSSRulesImportOptions{
+		ConflictPolicy: RSSRuleConflictRename,
+		FeedURLs: map[string]string{
+			"https://tracker.example.com/rss?passkey=old": "https://tracker.example.com/rss?passkey=new",
+		},
+	})
+	expectImportedAs(imports, map[string]string{"movies": "movies", "show": "show (3)", "show (2)": "show (2)"})
+
+	// ── feed URLs remapping ─────────────────────────────────
+	for _, ruleImport := range imports {
+		if ruleImport.Name != "show" {
+			continue
+		}
+		if !ruleImport.Conflict || ruleImport.Rule.AffectedFeeds[0] != "https://tracker.example.com/rss?passkey=new" ||
+			ruleImport.Rule.AffectedFeeds[1] != "https://other.example.com/rss" {
+			t.Fatalf("feed URLs not remapped: %+v", ruleImport)
+		}
+	}
+	if rules["show"].AffectedFeeds[0] != "https://tracker.example.com/rss?passkey=old" {
+		t.Fatal("remapping altered the source rules")
+	}
+}
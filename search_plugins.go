@@ -0,0 +1,230 @@
+package qbtapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+	Search plugins helpers
+*/
+
+const (
+	// searchPluginsPollInterval is the interval between two plugins listings while waiting for asynchronous changes
+	searchPluginsPollInterval = time.Second
+	// DefaultSearchPluginsUpdateSettle is the default time without any version change after which an update is considered done
+	DefaultSearchPluginsUpdateSettle = 10 * time.Second
+)
+
+// InstallSearchPluginFileOptions holds the options of InstallSearchPluginFile(). All fields are optional.
+type InstallSearchPluginFileOptions struct {
+	// Address the temporary file server listens on. Default is "127.0.0.1:0", suitable when
+	// qBittorrent runs on the same host.
+	ListenAddress string
+	// Host (and port) qBittorrent must use to reach the temporary file server. Default is the listening address.
+	AdvertisedHost string
+}
+
+// InstallSearchPluginFile installs a search plugin from the content of its Python file. As the API can only install
+// plugins from URLs or server side paths, the file is served by a temporary HTTP server which qBittorrent must be
+// able to reach (see options, which can be nil). The plugin name is fileName without its ".py" extension.
+// The call blocks until the plugin appears within the installed plugins (or its version changes if it was
+// already installed): use a ctx with a deadline as a failed installation is not reported by the API.
+func (c *Client) InstallSearchPluginFile(ctx context.Context, fileName string, content []byte, options *InstallSearchPluginFileOptions) (plugin SearchPlugin, err error) {
+	fileName = path.Base(fileName)
+	name, isPython := strings.CutSuffix(fileName, ".py")
+	if !isPython || name == "" {
+		err = fmt.Errorf("plugin file name must end with .py: %q", fileName)
+		return
+	}
+	listenAddress, advertisedHost := "127.0.0.1:0", ""
+	if options != nil {
+		if options.ListenAddress != "" {
+			listenAddress = options.ListenAddress
+		}
+		advertisedHost = options.AdvertisedHost
+	}
+	// previous version, if any
+	plugins, err := c.GetSearchPlugins(ctx)
+	if err != nil {
+		err = fmt.Errorf("getting installed plugins failed: %w", err)
+		return
+	}
+	var previous *SearchPlugin
+	for index := range plugins {
+		if plugins[index].Name == name {
+			previous = &plugins[index]
+			break
+		}
+	}
+	// serve the file
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		err = fmt.Errorf("listening for the plugin file server failed: %w", err)
+		return
+	}
+	if advertisedHost == "" {
+		advertisedHost = listener.Addr().String()
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+fileName, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/x-python")
+		_, _ = w.Write(content)
+	})
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+	// install and wait for it
+	if err = c.InstallSearchPlugin(ctx, []string{"http://" + advertisedHost + "/" + fileName}); err != nil {
+		err = fmt.Errorf("installing plugin failed: %w", err)
+		return
+	}
+	ticker := time.NewTicker(searchPluginsPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			err = fmt.Errorf("waiting for plugin %q to be installed failed: %w", name, ctx.Err())
+			return
+		case <-ticker.C:
+		}
+		if plugins, err = c.GetSearchPlugins(ctx); err != nil {
+			err = fmt.Errorf("getting installed plugins failed: %w", err)
+			return
+		}
+		for _, installed := range plugins {
+			if installed.Name == name && (previous == nil || installed.Version != previous.Version) {
+				plugin = installed
+				return
+			}
+		}
+	}
+}
+
+// SearchPluginUpdate describes the version change of a search plugin.
+type SearchPluginUpdate struct {
+	Name       string
+	OldVersion string // Empty if the plugin was installed
+	NewVersion string // Empty if the plugin was removed
+}
+
+// DiffSearchPlugins returns the plugins whose version differs between two listings, sorted by name.
+func DiffSearchPlugins(before, after []SearchPlugin) (updates []SearchPluginUpdate) {
+	versions := make(map[string]*SearchPluginUpdate, len(before)+len(after))
+	for _, plugin := range before {
+		versions[plugin.Name] = &SearchPluginUpdate{
+			Name:       plugin.Name,
+			OldVersion: plugin.Version,
+		}
+	}
+	for _, plugin := range after {
+		if update, found := versions[plugin.Name]; found {
+			update.NewVersion = plugin.Version
+		} else {
+			versions[plugin.Name] = &SearchPluginUpdate{
+				Name:       plugin.Name,
+				NewVersion: plugin.Version,
+			}
+		}
+	}
+	for _, name := range sortedKeys(versions) {
+		if update := versions[name]; update.OldVersion != update.NewVersion {
+			updates = append(updates, *update)
+		}
+	}
+	return
+}
+
+// UpdateSearchPluginsAndReport updates all search plugins and reports the version changes. As updates are
+// applied asynchronously by the server, plugins are listed until no change has been seen for settle
+// (DefaultSearchPluginsUpdateSettle if 0). If ctx is done first, the changes seen until then are returned along
+// with the context error.
+func (c *Client) UpdateSearchPluginsAndReport(ctx context.Context, settle time.Duration) (updates []SearchPluginUpdate, err error) {
+	if settle <= 0 {
+		settle = DefaultSearchPluginsUpdateSettle
+	}
+	before, err := c.GetSearchPlugins(ctx)
+	if err != nil {
+		err = fmt.Errorf("getting installed plugins failed: %w", err)
+		return
+	}
+	if err = c.UpdateSearchPlugins(ctx); err != nil {
+		err = fmt.Errorf("updating plugins failed: %w", err)
+		return
+	}
+	ticker := time.NewTicker(searchPluginsPollInterval)
+	defer ticker.Stop()
+	last, lastChange := before, time.Now()
+	for time.Since(lastChange) < settle {
+		select {
+		case <-ctx.Done():
+			err = fmt.Errorf("waiting for plugins updates to settle failed: %w", ctx.Err())
+			return
+		case <-ticker.C:
+		}
+		after, listErr := c.GetSearchPlugins(ctx)
+		if listErr != nil {
+			if ctx.Err() != nil {
+				err = fmt.Errorf("waiting for plugins updates to settle failed: %w", ctx.Err())
+			} else {
+				err = fmt.Errorf("getting installed plugins failed: %w", listErr)
+			}
+			return
+		}
+		if len(DiffSearchPlugins(last, after)) > 0 {
+			updates = DiffSearchPlugins(before, after)
+			last, lastChange = after, time.Now()
+		}
+	}
+	return
+}
+
+// ErrUnsupportedSearchCategory is returned when a search category is not supported by the selected plugins.
+var ErrUnsupportedSearchCategory = errors.New("unsupported search category")
+
+// ValidateSearchCategory checks that category is supported by at least one of the plugins selected by
+// pluginsSelection (plugin names separated by '|', "enabled" or "all", as expected by StartSearch()),
+// plugins not supporting a category being skipped by the search. The "all" category is always valid.
+func ValidateSearchCategory(installed []SearchPlugin, pluginsSelection, category string) error {
+	if category == "" || category == "all" {
+		return nil
+	}
+	var selected map[string]struct{}
+	if pluginsSelection != "all" && pluginsSelection != "enabled" {
+		selected = make(map[string]struct{})
+		for _, name := range strings.Split(pluginsSelection, "|") {
+			selected[name] = struct{}{}
+		}
+	}
+	var candidates []string
+	for _, plugin := range installed {
+		if selected != nil {
+			if _, found := selected[plugin.Name]; !found {
+				continue
+			}
+		} else if pluginsSelection == "enabled" && !plugin.Enabled {
+			continue
+		}
+		for _, supportedCategory := range plugin.SupportedCategories {
+			if supportedCategory.ID == category {
+				return nil
+			}
+		}
+		candidates = append(candidates, plugin.Name)
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("no installed plugin matches %q", pluginsSelection)
+	}
+	sort.Strings(candidates)
+	return fmt.Errorf("%w %q: not supported by any of %s", ErrUnsupportedSearchCategory, category, strings.Join(candidates, ", "))
+}
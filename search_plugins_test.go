@@ -0,0 +1,174 @@
+package qbtapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInstallSearchPluginFile(t *testing.T) {
+	// fake instance downloading the plugin file from the given source
+	var (
+		access    sync.Mutex
+		installed []SearchPlugin
+		fetched   string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/search/plugins", func(w http.ResponseWriter, r *http.Request) {
+		access.Lock()
+		defer access.Unlock()
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		_ = json.NewEncoder(w).Encode(installed)
+	})
+	mux.HandleFunc("/api/v2/search/installPlugin", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		source := r.PostForm.Get("sources")
+		resp, err := http.Get(source)
+		if err != nil {
+			t.Errorf("fetching plugin source: %v", err)
+			return
+		}
+		content, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		access.Lock()
+		fetched = string(content)
+		installed = append(installed, SearchPlugin{
+			Name:    strings.TrimSuffix(path.Base(source), ".py"),
+			Version: "1.0",
+		})
+		access.Unlock()
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	endpoint, _ := url.Parse(server.URL)
+	c, err := New(endpoint, "user", "pass")
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err = c.InstallSearchPluginFile(ctx, "myplugin.txt", nil, nil); err == nil {
+		t.Error("expected an error for a non python file name")
+	}
+	plugin, err := c.InstallSearchPluginFile(ctx, "/some/dir/myplugin.py", []byte("#VERSION: 1.0"), nil)
+	if err != nil {
+		t.Fatalf("InstallSearchPluginFile: %v", err)
+	}
+	if plugin.Name != "myplugin" || plugin.Version != "1.0" {
+		t.Errorf("unexpected plugin: %+v", plugin)
+	}
+	if fetched != "#VERSION: 1.0" {
+		t.Errorf("unexpected served content: %q", fetched)
+	}
+}
+
+func TestDiffSearchPlugins(t *testing.T) {
+	before := []SearchPlugin{
+		{Name: "eztv", Version: "1.0"},
+		{Name: "jackett", Version: "4.0"},
+		{Name: "limetorrents", Version: "4.7"},
+	}
+	after := []SearchPlugin{
+		{Name: "limetorrents", Version: "4.8"},
+		{Name: "eztv", Version: "1.0"},
+		{Name: "torlock", Version: "2.2"},
+	}
+	updates := DiffSearchPlugins(before, after)
+	expected := []SearchPluginUpdate{
+		{Name: "jackett", OldVersion: "4.0"},
+		{Name: "limetorrents", OldVersion: "4.7", NewVersion: "4.8"},
+		{Name: "torlock", NewVersion: "2.2"},
+	}
+	if len(updates) != len(expected) {
+		t.Fatalf("expected %d updates, got %+v", len(expected), updates)
+	}
+	for index := range expected {
+		if updates[index] != expected[index] {
+			t.Errorf("update %d: expected %+v, got %+v", index, expected[index], updates[index])
+		}
+	}
+	if updates = DiffSearchPlugins(before, before); len(updates) != 0 {
+		t.Errorf("expected no updates, got %+v", updates)
+	}
+}
+
+func TestUpdateSearchPluginsAndReportCancel(t *testing.T) {
+	var (
+		access  sync.Mutex
+		version = "1.0"
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/search/plugins", func(w http.ResponseWriter, r *http.Request) {
+		access.Lock()
+		defer access.Unlock()
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		_ = json.NewEncoder(w).Encode([]SearchPlugin{{Name: "eztv", Version: version}})
+	})
+	mux.HandleFunc("/api/v2/search/updatePlugins", func(w http.ResponseWriter, r *http.Request) {
+		access.Lock()
+		defer access.Unlock()
+		version = "1.1"
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	endpoint, _ := url.Parse(server.URL)
+	c, err := New(endpoint, "user", "pass")
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), searchPluginsPollInterval*3/2)
+	defer cancel()
+
+	updates, err := c.UpdateSearchPluginsAndReport(ctx, time.Minute)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the context error, got %v", err)
+	}
+	if len(updates) != 1 || updates[0] != (SearchPluginUpdate{Name: "eztv", OldVersion: "1.0", NewVersion: "1.1"}) {
+		t.Errorf("expected the changes seen before cancellation, got %+v", updates)
+	}
+}
+
+func TestValidateSearchCategory(t *testing.T) {
+	installed := []SearchPlugin{
+		{
+			Name:                "eztv",
+			Enabled:             true,
+			SupportedCategories: []SearchPluginCategory{{ID: "all"}, {ID: "tv"}},
+		},
+		{
+			Name:                "piratebay",
+			Enabled:             false,
+			SupportedCategories: []SearchPluginCategory{{ID: "all"}, {ID: "movies"}, {ID: "tv"}},
+		},
+	}
+	for _, tc := range []struct {
+		plugins, category string
+		valid             bool
+	}{
+		{"enabled", "all", true},
+		{"enabled", "tv", true},
+		{"enabled", "movies", false},
+		{"all", "movies", true},
+		{"eztv|piratebay", "movies", true},
+		{"eztv", "movies", false},
+		{"unknown", "tv", false},
+	} {
+		err := ValidateSearchCategory(installed, tc.plugins, tc.category)
+		if (err == nil) != tc.valid {
+			t.Errorf("%s/%s: expected valid=%v, got %v", tc.plugins, tc.category, tc.valid, err)
+		}
+	}
+	if err := ValidateSearchCategory(installed, "enabled", "movies"); !errors.Is(err, ErrUnsupportedSearchCategory) {
+		t.Errorf("expected ErrUnsupportedSearchCategory, got %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package qbtapi
+
+import (
+	"context"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/*
+	Search results deduplication, ranking and download
+*/
+
+// SearchResultInfoHash returns the lowercase hexadecimal info hash of a result whose FileURL is a magnet link
+// (v1 "btih" hashes, either in hexadecimal or base32, and v2 "btmh" hashes are supported) or an empty string.
+func SearchResultInfoHash(result SearchResult) string {
+	magnet, err := url.Parse(result.FileURL)
+	if err != nil || magnet.Scheme != "magnet" {
+		return ""
+	}
+	for _, xt := range magnet.Query()["xt"] {
+		switch {
+		case strings.HasPrefix(strings.ToLower(xt), "urn:btih:"):
+			hash := xt[len("urn:btih:"):]
+			switch len(hash) {
+			case 40:
+				if _, err = hex.DecodeString(hash); err == nil {
+					return strings.ToLower(hash)
+				}
+			case 32:
+				if decoded, err := base32.StdEncoding.DecodeString(strings.ToUpper(hash)); err == nil {
+					return hex.EncodeToString(decoded)
+				}
+			}
+		case strings.HasPrefix(strings.ToLower(xt), "urn:btmh:"):
+			// multihash: 0x12 (sha2-256) 0x20 (32 bytes) then the hash
+			hash := strings.ToLower(xt[len("urn:btmh:"):])
+			if strings.HasPrefix(hash, "1220") && len(hash) == 68 {
+				if _, err = hex.DecodeString(hash[4:]); err == nil {
+					return hash[4:]
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// NormalizeSearchResultName lowercases a result name and replaces the separators commonly used
+// in release names (dots, underscores, dashes, brackets, etc...) by single spaces.
+func NormalizeSearchResultName(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}), " ")
+}
+
+// SearchResultKey identifies the torrent behind a result: its info hash if known (see SearchResultInfoHash()),
+// its normalized name (see NormalizeSearchResultName()) and size otherwise.
+func SearchResultKey(result SearchResult) string {
+	if hash := SearchResultInfoHash(result); hash != "" {
+		return "hash:" + hash
+	}
+	return "name:" + NormalizeSearchResultName(result.FileName) + "|" + strconv.FormatInt(result.FileSize, 10)
+}
+
+// SearchResultGroup gathers the results pointing to the same torrent.
+type SearchResultGroup struct {
+	Key        string         // See SearchResultKey()
+	Best       SearchResult   // Result with the most seeders (first found on ties)
+	Duplicates []SearchResult // Other results, in their original order
+}
+
+// DedupeSearchResults groups results pointing to the same torrent (see SearchResultKey()).
+// Groups are returned in order of first appearance.
+func DedupeSearchResults(results []SearchResult) (groups []SearchResultGroup) {
+	index := make(map[string]int, len(results))
+	for _, result := range results {
+		key := SearchResultKey(result)
+		position, found := index[key]
+		if !found {
+			index[key] = len(groups)
+			groups = append(groups, SearchResultGroup{
+				Key:  key,
+				Best: result,
+			})
+			continue
+		}
+		group := &groups[position]
+		if result.NbSeeders > group.Best.NbSeeders {
+			group.Best, result = result, group.Best
+		}
+		group.Duplicates = append(group.Duplicates, result)
+	}
+	return
+}
+
+// SearchResultScorer scores a search result: the higher the better.
+type SearchResultScorer func(result SearchResult) float64
+
+// DefaultSearchResultScorer favors well seeded results, leechers counting less than seeders,
+// and penalizes results without a known size. Unknown (negative) peer counts count as 0.
+func DefaultSearchResultScorer(result SearchResult) (score float64) {
+	score = 2*math.Log1p(math.Max(0, float64(result.NbSeeders))) + 0.5*math.Log1p(math.Max(0, float64(result.NbLeechers)))
+	if result.FileSize <= 0 {
+		score--
+	}
+	return
+}
+
+// WeightedSearchResultScorer associates a scorer with its weight within CombineSearchResultScorers().
+type WeightedSearchResultScorer struct {
+	Scorer SearchResultScorer
+	Weight float64
+}
+
+// CombineSearchResultScorers returns a scorer summing the weighted scores of several scorers,
+// allowing to add user preferences (preferred sites, size ranges, keywords) to the default heuristics.
+func CombineSearchResultScorers(scorers ...WeightedSearchResultScorer) SearchResultScorer {
+	return func(result SearchResult) (score float64) {
+		for _, ws := range scorers {
+			score += ws.Weight * ws.Scorer(result)
+		}
+		return
+	}
+}
+
+// RankedSearchResult is a search result with its score.
+type RankedSearchResult struct {
+	SearchResult
+	Score float64
+}
+
+// RankSearchResults scores results with scorer (DefaultSearchResultScorer if nil) and returns them best first.
+// Ties keep their original order.
+func RankSearchResults(results []SearchResult, scorer SearchResultScorer) (ranked []RankedSearchResult) {
+	if scorer == nil {
+		scorer = DefaultSearchResultScorer
+	}
+	ranked = make([]RankedSearchResult, len(results))
+	for index, result := range results {
+		ranked[index] = RankedSearchResult{
+			SearchResult: result,
+			Score:        scorer(result),
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+	return
+}
+
+// DownloadSearchResult adds the torrent of a search result. options can be nil.
+// Without options, the torrent is downloaded through the plugin which found it (see DownloadSearchTorrent()),
+// which allows plugins requiring a login to fetch it. With options, or if the plugin is unknown,
+// the result URL is added with AddNewTorrents() so the options can be applied.
+func (c *Client) DownloadSearchResult(ctx context.Context, result SearchResult, options *AddNewTorrentsOptions) (err error) {
+	if options == nil && result.EngineName != "" {
+		return c.DownloadSearchTorrent(ctx, result.FileURL, result.EngineName)
+	}
+	torrentURL, err := url.Parse(result.FileURL)
+	if err != nil {
+		err = fmt.Errorf("parsing result URL failed: %w", err)
+		return
+	}
+	return c.AddNewTorrents(ctx, nil, []*url.URL{torrentURL}, options)
+}
@@ -0,0 +1,58 @@
+package qbtapi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSearchResultsDedupeAndRank(t *testing.T) {
+	const hash = "c9e15763f722f23e98a29decdfae341b98d53056"
+	results := []SearchResult{
+		{FileName: "Ubuntu 24.04 Desktop", FileURL: "magnet:?xt=urn:btih:" + strings.ToUpper(hash) + "&dn=ubuntu", NbSeeders: 10, EngineName: "a"},
+		{FileName: "ubuntu.24.04.desktop", FileURL: "https://example.com/ubuntu.torrent", FileSize: 6 << 30, NbSeeders: 3, EngineName: "b"},
+		{FileName: "Ubuntu-24.04-Desktop", FileURL: "magnet:?xt=urn:btih:ZHQVOY7XELZD5GFCTXWN7LRUDOMNKMCW", NbSeeders: 50, EngineName: "c"},
+		{FileName: "Ubuntu_24.04_Desktop", FileURL: "https://mirror.example.com/dl/42", FileSize: 6 << 30, NbSeeders: 1, NbLeechers: 200, EngineName: "d"},
+		{FileName: "Debian 12", FileURL: "https://example.com/debian.torrent", FileSize: 4 << 30, NbSeeders: -1, EngineName: "a"},
+	}
+
+	// ── info hash and keys ──────────────────────────────────
+	if got := SearchResultInfoHash(results[0]); got != hash {
+		t.Fatalf("hex info hash: expected %s, got %s", hash, got)
+	}
+	if got := SearchResultInfoHash(results[2]); got != hash {
+		t.Fatalf("base32 info hash: expected %s, got %s", hash, got)
+	}
+	if SearchResultKey(results[1]) != SearchResultKey(results[3]) {
+		t.Fatalf("name+size keys differ: %s vs %s", SearchResultKey(results[1]), SearchResultKey(results[3]))
+	}
+
+	// ── dedupe ──────────────────────────────────────────────
+	groups := DedupeSearchResults(results)
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %+v", groups)
+	}
+	if groups[0].Best.EngineName != "c" || len(groups[0].Duplicates) != 1 || groups[0].Duplicates[0].EngineName != "a" {
+		t.Fatalf("magnet group: unexpected best or duplicates: %+v", groups[0])
+	}
+	if groups[1].Best.EngineName != "b" || len(groups[1].Duplicates) != 1 {
+		t.Fatalf("name+size group: unexpected best or duplicates: %+v", groups[1])
+	}
+
+	// ── ranking ─────────────────────────────────────────────
+	ranked := RankSearchResults(results, nil)
+	if ranked[0].EngineName != "c" || ranked[len(ranked)-1].FileName != "Debian 12" {
+		t.Fatalf("unexpected default ranking: %+v", ranked)
+	}
+	preferDebian := CombineSearchResultScorers(
+		WeightedSearchResultScorer{Scorer: DefaultSearchResultScorer, Weight: 1},
+		WeightedSearchResultScorer{Scorer: func(result SearchResult) float64 {
+			if strings.Contains(NormalizeSearchResultName(result.FileName), "debian") {
+				return 100
+			}
+			return 0
+		}, Weight: 1},
+	)
+	if ranked = RankSearchResults(results, preferDebian); ranked[0].FileName != "Debian 12" {
+		t.Fatalf("user scorer not applied: %+v", ranked)
+	}
+}
@@ -0,0 +1,119 @@
+package qbtapi
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+)
+
+/*
+	Blocking search helper
+*/
+
+const (
+	// DefaultSearchPollInterval is the interval between two results fetches of Search() if not specified
+	DefaultSearchPollInterval = time.Second
+	// searchCleanupTimeout bounds the time spent stopping and deleting a search job once done
+	searchCleanupTimeout = 10 * time.Second
+)
+
+// SearchOptions holds the options of Search(). All fields are optional.
+type SearchOptions struct {
+	Plugins      string        // Plugins to use separated by '|', "enabled" or "all". Default is "enabled".
+	Category     string        // Category to search in or "all". Default is "all".
+	MaxDuration  time.Duration // Stop the search after this duration, 0 means until the search job stops by itself
+	MaxResults   int           // Stop the search after this many results, 0 means no limit
+	PollInterval time.Duration // Interval between two results fetches, default is DefaultSearchPollInterval
+}
+
+// Search starts a search job and returns an iterator over its results, streamed as they arrive.
+// If a category is specified, it is first validated against the selected plugins (see ValidateSearchCategory()).
+// Iteration ends when the job stops by itself, MaxDuration or MaxResults is reached, or the loop is exited.
+// If an error occurs (including ctx cancellation), it is yielded as the last element.
+// The search job is always stopped and deleted once iteration ends, even if ctx has been cancelled.
+// options can be nil.
+func (c *Client) Search(ctx context.Context, query string, options *SearchOptions) iter.Seq2[SearchResult, error] {
+	plugins, category, pollInterval := "enabled", "all", DefaultSearchPollInterval
+	var maxDuration time.Duration
+	var maxResults int
+	if options != nil {
+		if options.Plugins != "" {
+			plugins = options.Plugins
+		}
+		if options.Category != "" {
+			category = options.Category
+		}
+		if options.PollInterval > 0 {
+			pollInterval = options.PollInterval
+		}
+		maxDuration = options.MaxDuration
+		maxResults = options.MaxResults
+	}
+	return func(yield func(SearchResult, error) bool) {
+		if category != "all" {
+			installed, err := c.GetSearchPlugins(ctx)
+			if err != nil {
+				yield(SearchResult{}, fmt.Errorf("getting search plugins failed: %w", err))
+				return
+			}
+			if err = ValidateSearchCategory(installed, plugins, category); err != nil {
+				yield(SearchResult{}, err)
+				return
+			}
+		}
+		jobID, err := c.StartSearch(ctx, query, plugins, category)
+		if err != nil {
+			yield(SearchResult{}, fmt.Errorf("starting search failed: %w", err))
+			return
+		}
+		defer c.cleanupSearch(ctx, jobID)
+		var deadline <-chan time.Time
+		if maxDuration > 0 {
+			deadlineTimer := time.NewTimer(maxDuration)
+			defer deadlineTimer.Stop()
+			deadline = deadlineTimer.C
+		}
+		pollTimer := time.NewTimer(0)
+		defer pollTimer.Stop()
+		var offset int
+		for {
+			select {
+			case <-ctx.Done():
+				yield(SearchResult{}, ctx.Err())
+				return
+			case <-deadline:
+				return
+			case <-pollTimer.C:
+			}
+			results, err := c.GetSearchResults(ctx, jobID, nil, &offset)
+			if err != nil {
+				yield(SearchResult{}, fmt.Errorf("getting search results failed: %w", err))
+				return
+			}
+			for _, result := range results.Results {
+				if !yield(result, nil) {
+					return
+				}
+				offset++
+				if maxResults > 0 && offset >= maxResults {
+					return
+				}
+			}
+			// results of a stopped job are complete
+			if results.Status == SearchJobStatusStopped {
+				return
+			}
+			pollTimer.Reset(pollInterval)
+		}
+	}
+}
+
+// cleanupSearch stops and deletes a search job, even if ctx has been cancelled.
+func (c *Client) cleanupSearch(ctx context.Context, jobID int) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), searchCleanupTimeout)
+	defer cancel()
+	// stopping an already stopped job is harmless, deleting a running one is not supported by all versions
+	_ = c.StopSearch(ctx, jobID)
+	_ = c.DeleteSearch(ctx, jobID)
+}
@@ -0,0 +1,116 @@
+package qbtapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSearch(t *testing.T) {
+	// ── fake instance ───────────────────────────────────────
+	var (
+		access  sync.Mutex
+		polls   int
+		deleted []int
+		nextID  int
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/search/start", func(w http.ResponseWriter, r *http.Request) {
+		access.Lock()
+		nextID++
+		id := nextID
+		polls = 0
+		access.Unlock()
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		_, _ = w.Write([]byte(`{"id":` + strconv.Itoa(id) + `}`))
+	})
+	mux.HandleFunc("/api/v2/search/results", func(w http.ResponseWriter, r *http.Request) {
+		access.Lock()
+		polls++
+		poll := polls
+		access.Unlock()
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		// 2 results per poll, job stops after 3 polls
+		status := "Running"
+		if poll >= 3 {
+			status = "Stopped"
+		}
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		results := `[{"fileName":"result ` + strconv.Itoa(offset) + `"},{"fileName":"result ` + strconv.Itoa(offset+1) + `"}]`
+		_, _ = w.Write([]byte(`{"status":"` + status + `","total":` + strconv.Itoa(offset+2) + `,"results":` + results + `}`))
+	})
+	mux.HandleFunc("/api/v2/search/stop", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/api/v2/search/delete", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		id, _ := strconv.Atoi(r.PostForm.Get("id"))
+		access.Lock()
+		deleted = append(deleted, id)
+		access.Unlock()
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	endpoint, _ := url.Parse(server.URL)
+	c, err := New(endpoint, "user", "pass")
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	collect := func(ctx context.Context, options *SearchOptions) (names []string, err error) {
+		for result, resultErr := range c.Search(ctx, "ubuntu", options) {
+			if resultErr != nil {
+				err = resultErr
+				break
+			}
+			names = append(names, result.FileName)
+		}
+		return
+	}
+	fast := &SearchOptions{PollInterval: time.Millisecond}
+
+	// ── until the job stops ─────────────────────────────────
+	names, err := collect(context.Background(), fast)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(names) != 6 || names[0] != "result 0" || names[5] != "result 5" {
+		t.Fatalf("results not paged with offsets: %v", names)
+	}
+
+	// ── max results ─────────────────────────────────────────
+	names, err = collect(context.Background(), &SearchOptions{PollInterval: time.Millisecond, MaxResults: 3})
+	if err != nil {
+		t.Fatalf("Search (max results): %v", err)
+	}
+	if len(names) != 3 {
+		t.Fatalf("expected 3 results, got %v", names)
+	}
+
+	// ── cancellation ────────────────────────────────────────
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = collect(ctx, fast); err == nil {
+		t.Fatal("expected an error with a cancelled context")
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	var lastErr error
+	for _, resultErr := range c.Search(ctx, "ubuntu", &SearchOptions{PollInterval: time.Hour}) {
+		lastErr = resultErr
+		cancel()
+	}
+	if !errors.Is(lastErr, context.Canceled) {
+		t.Fatalf("expected the cancellation to be yielded, got %v", lastErr)
+	}
+
+	// ── cleanup ─────────────────────────────────────────────
+	access.Lock()
+	defer access.Unlock()
+	if len(deleted) != 3 || deleted[0] != 1 || deleted[1] != 2 || deleted[2] != 3 {
+		t.Fatalf("search jobs not all deleted: %v", deleted)
+	}
+}
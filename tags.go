@@ -0,0 +1,221 @@
+package qbtapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+/*
+	Tags reconciliation
+*/
+
+// ErrInvalidTag is returned for tags the server can not receive: empty (once trimmed) or containing a comma,
+// the separator of the tags request parameter.
+var ErrInvalidTag = errors.New("invalid tag")
+
+// ValidateTag returns ErrInvalidTag (wrapped) if tag can not be sent to the server.
+func ValidateTag(tag string) (err error) {
+	switch {
+	case strings.TrimSpace(tag) == "":
+		err = fmt.Errorf("%w: empty tag", ErrInvalidTag)
+	case strings.Contains(tag, tagListSeparator):
+		err = fmt.Errorf("%w: %q contains %q", ErrInvalidTag, tag, tagListSeparator)
+	}
+	return
+}
+
+func validateTags(tags []string) (err error) {
+	for _, tag := range tags {
+		if err = ValidateTag(tag); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// parseTagList splits the tag list of a torrent as sent by the server. The server joins the tags with ", " which
+// is ambiguous: a tag containing ", " can not be recovered from the list response and comes back split in several
+// tags. Surrounding whitespaces are trimmed.
+func parseTagList(tags string) (parsed []string) {
+	if strings.TrimSpace(tags) == "" {
+		return
+	}
+	parsed = strings.Split(tags, tagListResponseSeparator)
+	for index := range parsed {
+		parsed[index] = strings.TrimSpace(parsed[index])
+	}
+	return
+}
+
+// SetTorrentTags makes the tags of one or more torrents exactly equal to tags (an empty set removes all their tags).
+// Uses torrents/setTags if the server supports it (see APIFeatureSetTags), otherwise the current tags of the torrents
+// are fetched and the missing ones added then the extra ones removed.
+func (c *Client) SetTorrentTags(ctx context.Context, hashes []string, tags []string) (err error) {
+	if len(hashes) == 0 {
+		return
+	}
+	if err = validateTags(tags); err != nil {
+		return
+	}
+	supported, err := c.Supports(ctx, APIFeatureSetTags)
+	if err != nil {
+		return
+	}
+	if supported {
+		return c.setTorrentTags(ctx, hashes, tags)
+	}
+	torrents, err := c.GetTorrentList(ctx, &ListFilters{Hashes: hashes})
+	if err != nil {
+		err = fmt.Errorf("getting torrents tags failed: %w", err)
+		return
+	}
+	// group torrents by tags to add and remove to minimize calls
+	type tagsChange struct {
+		tags   []string
+		hashes []string
+	}
+	wanted := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		wanted[strings.TrimSpace(tag)] = struct{}{}
+	}
+	toAdd := make(map[string]*tagsChange)    // tags (joined) -> change
+	toRemove := make(map[string]*tagsChange) // tags (joined) -> change
+	group := func(changes map[string]*tagsChange, tags []string, hash string) {
+		key := strings.Join(tags, "\n")
+		if changes[key] == nil {
+			changes[key] = &tagsChange{tags: tags}
+		}
+		changes[key].hashes = append(changes[key].hashes, hash)
+	}
+	for _, torrent := range torrents {
+		var adding, removing []string
+		for _, tag := range sortedKeys(wanted) {
+			if !slices.Contains(torrent.Tags, tag) {
+				adding = append(adding, tag)
+			}
+		}
+		for _, tag := range torrent.Tags {
+			if _, found := wanted[tag]; !found {
+				removing = append(removing, tag)
+			}
+		}
+		if len(adding) > 0 {
+			group(toAdd, adding, torrent.Hash)
+		}
+		if len(removing) > 0 {
+			sort.Strings(removing)
+			group(toRemove, removing, torrent.Hash)
+		}
+	}
+	for _, key := range sortedKeys(toAdd) {
+		if err = c.AddTorrentTags(ctx, toAdd[key].hashes, toAdd[key].tags); err != nil {
+			err = fmt.Errorf("adding tags %q failed: %w", toAdd[key].tags, err)
+			return
+		}
+	}
+	for _, key := range sortedKeys(toRemove) {
+		if err = c.RemoveTorrentTags(ctx, toRemove[key].hashes, toRemove[key].tags); err != nil {
+			err = fmt.Errorf("removing tags %q failed: %w", toRemove[key].tags, err)
+			return
+		}
+	}
+	return
+}
+
+// GroupTorrentsByTag groups torrents by tag, a torrent appearing in each of its tags groups.
+// Torrents without tags are grouped under the empty tag.
+func GroupTorrentsByTag(torrents []TorrentInfos) (groups map[string][]TorrentInfos) {
+	groups = make(map[string][]TorrentInfos)
+	for _, torrent := range torrents {
+		if len(torrent.Tags) == 0 {
+			groups[""] = append(groups[""], torrent)
+			continue
+		}
+		for _, tag := range torrent.Tags {
+			groups[tag] = append(groups[tag], torrent)
+		}
+	}
+	return
+}
+
+// MergeTags replaces the sources tags by target on all torrents then deletes the sources tags.
+// The hashes of the retagged torrents are returned, sorted.
+func (c *Client) MergeTags(ctx context.Context, sources []string, target string) (hashes []string, err error) {
+	if err = validateTags(append([]string{target}, sources...)); err != nil {
+		return
+	}
+	sources = slices.DeleteFunc(slices.Clone(sources), func(source string) bool { return source == target })
+	if len(sources) == 0 {
+		return
+	}
+	if err = c.CreateTags(ctx, []string{target}); err != nil {
+		err = fmt.Errorf("creating tag %q failed: %w", target, err)
+		return
+	}
+	torrents, err := c.GetTorrentList(ctx, nil)
+	if err != nil {
+		err = fmt.Errorf("listing torrents failed: %w", err)
+		return
+	}
+	groups := GroupTorrentsByTag(torrents)
+	retagged := make(map[string]struct{})
+	for _, source := range sources {
+		for _, torrent := range groups[source] {
+			retagged[torrent.Hash] = struct{}{}
+		}
+	}
+	hashes = sortedKeys(retagged)
+	if len(hashes) > 0 {
+		if err = c.AddTorrentTags(ctx, hashes, []string{target}); err != nil {
+			err = fmt.Errorf("adding tag %q failed: %w", target, err)
+			return
+		}
+		if err = c.RemoveTorrentTags(ctx, hashes, sources); err != nil {
+			err = fmt.Errorf("removing tags failed: %w", err)
+			return
+		}
+	}
+	if err = c.DeleteTags(ctx, sources); err != nil {
+		err = fmt.Errorf("deleting tags failed: %w", err)
+	}
+	return
+}
+
+// RenameTag renames the oldTag tag to newTag on all torrents, merging it if newTag already exists.
+// The hashes of the retagged torrents are returned, sorted.
+func (c *Client) RenameTag(ctx context.Context, oldTag, newTag string) (hashes []string, err error) {
+	return c.MergeTags(ctx, []string{oldTag}, newTag)
+}
+
+// DeleteUnusedTags deletes the tags (as returned by GetAllTags()) not used by any torrent and returns them, sorted.
+// With dryRun the unused tags are only returned.
+func (c *Client) DeleteUnusedTags(ctx context.Context, dryRun bool) (unused []string, err error) {
+	tags, err := c.GetAllTags(ctx)
+	if err != nil {
+		err = fmt.Errorf("getting tags failed: %w", err)
+		return
+	}
+	torrents, err := c.GetTorrentList(ctx, nil)
+	if err != nil {
+		err = fmt.Errorf("listing torrents failed: %w", err)
+		return
+	}
+	groups := GroupTorrentsByTag(torrents)
+	for _, tag := range tags {
+		if len(groups[tag]) == 0 {
+			unused = append(unused, tag)
+		}
+	}
+	sort.Strings(unused)
+	if dryRun || len(unused) == 0 {
+		return
+	}
+	if err = c.DeleteTags(ctx, unused); err != nil {
+		err = fmt.Errorf("deleting tags failed: %w", err)
+	}
+	return
+}
@@ -0,0 +1,194 @@
+package qbtapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTagList(t *testing.T) {
+	var torrent TorrentInfos
+	if err := json.Unmarshal([]byte(`{"hash":"aaaa","tags":"hd, movies, x"}`), &torrent); err != nil {
+		t.Fatalf("unmarshaling torrent: %v", err)
+	}
+	if expected := []string{"hd", "movies", "x"}; !reflect.DeepEqual(torrent.Tags, expected) {
+		t.Errorf("tags: expected %q, got %q", expected, torrent.Tags)
+	}
+	if err := json.Unmarshal([]byte(`{"hash":"aaaa","tags":""}`), &torrent); err != nil || torrent.Tags != nil {
+		t.Errorf("empty tags: got %q (%v)", torrent.Tags, err)
+	}
+	for tag, valid := range map[string]bool{"hd": true, "with space": true, "": false, "  ": false, "a,b": false} {
+		if err := ValidateTag(tag); (err == nil) != valid || (err != nil && !errors.Is(err, ErrInvalidTag)) {
+			t.Errorf("ValidateTag(%q): unexpected result %v", tag, err)
+		}
+	}
+	groups := GroupTorrentsByTag([]TorrentInfos{
+		{Hash: "aaaa", Tags: []string{"hd", "movies"}},
+		{Hash: "bbbb", Tags: []string{"hd"}},
+		{Hash: "cccc"},
+	})
+	if len(groups) != 3 || len(groups["hd"]) != 2 || len(groups["movies"]) != 1 || len(groups[""]) != 1 || groups[""][0].Hash != "cccc" {
+		t.Errorf("GroupTorrentsByTag: unexpected groups %+v", groups)
+	}
+}
+
+func TestTags(t *testing.T) {
+	ctx := context.Background()
+
+	// ── fake instance ──────────────────────────────────────
+	var (
+		access  sync.Mutex
+		version = "2.11.2"
+		tags    = []string{"hd", "movies", "old", "unused"}
+		torrent = map[string][]string{ // hash -> tags
+			"aaaa": {"hd", "movies"},
+			"bbbb": {"old"},
+			"cccc": nil,
+		}
+		calls []string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/app/webapiVersion", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeHeader, contentTypeHeaderTextPlain)
+		access.Lock()
+		defer access.Unlock()
+		_, _ = w.Write([]byte(version))
+	})
+	mux.HandleFunc("/api/v2/torrents/tags", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		access.Lock()
+		defer access.Unlock()
+		_ = json.NewEncoder(w).Encode(tags)
+	})
+	mux.HandleFunc("/api/v2/torrents/info", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		access.Lock()
+		defer access.Unlock()
+		var filter []string
+		if hashes := r.URL.Query().Get("hashes"); hashes != "" {
+			filter = strings.Split(hashes, "|")
+		}
+		var torrents []map[string]string
+		for _, hash := range sortedKeys(torrent) {
+			if filter == nil || slices.Contains(filter, hash) {
+				torrents = append(torrents, map[string]string{"hash": hash, "tags": strings.Join(torrent[hash], ", ")})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(torrents)
+	})
+	for _, method := range []string{"setTags", "addTags", "removeTags", "createTags", "deleteTags"} {
+		mux.HandleFunc("/api/v2/torrents/"+method, func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			access.Lock()
+			defer access.Unlock()
+			call := method
+			if hashes := r.PostForm.Get("hashes"); hashes != "" {
+				call += " " + hashes
+			}
+			calls = append(calls, call+" "+r.PostForm.Get("tags"))
+		})
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("parsing test server URL: %v", err)
+	}
+	newClient := func() *Client {
+		t.Helper()
+		client, err := New(endpoint, "user", "pass")
+		if err != nil {
+			t.Fatalf("creating client: %v", err)
+		}
+		return client
+	}
+	client := newClient()
+	takeCalls := func() []string {
+		access.Lock()
+		defer access.Unlock()
+		taken := calls
+		calls = nil
+		return taken
+	}
+
+	// ── set tags ──────────────────────────────────────
+	if err = client.SetTorrentTags(ctx, []string{"aaaa"}, []string{"a,b"}); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("SetTorrentTags: expected ErrInvalidTag, got %v", err)
+	}
+	if err = client.SetTorrentTags(ctx, []string{"aaaa", "bbbb", "cccc"}, []string{"hd", "new"}); err != nil {
+		t.Fatalf("SetTorrentTags: %v", err)
+	}
+	taken := takeCalls()
+	sort.Strings(taken)
+	if expected := []string{
+		"addTags aaaa new",
+		"addTags bbbb|cccc hd,new",
+		"removeTags aaaa movies",
+		"removeTags bbbb old",
+	}; !reflect.DeepEqual(taken, expected) {
+		t.Errorf("SetTorrentTags: diff: expected calls %q, got %q", expected, taken)
+	}
+	access.Lock()
+	version = "2.11.4"
+	access.Unlock()
+	if err = newClient().SetTorrentTags(ctx, []string{"aaaa", "bbbb"}, nil); err != nil {
+		t.Fatalf("SetTorrentTags: %v", err)
+	}
+	if taken := takeCalls(); !reflect.DeepEqual(taken, []string{"setTags aaaa|bbbb "}) {
+		t.Errorf("SetTorrentTags: setTags: unexpected calls %q", taken)
+	}
+
+	// ── merge and rename ──────────────────────────────────────
+	hashes, err := client.MergeTags(ctx, []string{"hd", "old", "fhd"}, "fhd")
+	if err != nil {
+		t.Fatalf("MergeTags: %v", err)
+	}
+	if !reflect.DeepEqual(hashes, []string{"aaaa", "bbbb"}) {
+		t.Errorf("MergeTags: unexpected retagged torrents %v", hashes)
+	}
+	if expected := []string{
+		"createTags fhd",
+		"addTags aaaa|bbbb fhd",
+		"removeTags aaaa|bbbb hd,old",
+		"deleteTags hd,old",
+	}; !reflect.DeepEqual(takeCalls(), expected) {
+		t.Errorf("MergeTags: expected calls %q", expected)
+	}
+	if hashes, err = client.RenameTag(ctx, "movies", "films"); err != nil || !reflect.DeepEqual(hashes, []string{"aaaa"}) {
+		t.Errorf("RenameTag: unexpected result %v (%v)", hashes, err)
+	}
+	takeCalls()
+	if _, err = client.RenameTag(ctx, "movies", ""); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("RenameTag: expected ErrInvalidTag, got %v", err)
+	}
+
+	// ── unused tags ──────────────────────────────────────
+	unused, err := client.DeleteUnusedTags(ctx, true)
+	if err != nil {
+		t.Fatalf("DeleteUnusedTags: %v", err)
+	}
+	if !reflect.DeepEqual(unused, []string{"unused"}) {
+		t.Errorf("DeleteUnusedTags: unexpected unused tags %v", unused)
+	}
+	if taken := takeCalls(); len(taken) != 0 {
+		t.Errorf("DeleteUnusedTags: dry run deleted tags: %q", taken)
+	}
+	if _, err = client.DeleteUnusedTags(ctx, false); err != nil {
+		t.Fatalf("DeleteUnusedTags: %v", err)
+	}
+	if taken := takeCalls(); !reflect.DeepEqual(taken, []string{"deleteTags unused"}) {
+		t.Errorf("DeleteUnusedTags: unexpected calls %q", taken)
+	}
+}
@@ -0,0 +1,304 @@
+package qbtapi
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/*
+	Torrent content tree and file selection
+*/
+
+// TorrentContentNode is a file or a directory of a torrent content tree. See NewTorrentContentTree().
+type TorrentContentNode struct {
+	Name     string                // Node name, empty for the root node
+	Path     string                // Node path within the torrent ('/' separated), empty for the root node
+	File     *TorrentContent       // File of a file node, nil for directories
+	Children []*TorrentContentNode // Directory content, directories first then files, sorted by name
+	Size     int64                 // File size or total size of the directory files (bytes)
+	Progress float64               // File progress or directory progress over its files to download (all files if none), weighted by size
+	Priority FilePriority          // File priority or common priority of the directory files (FilePriorityMixed if they differ)
+}
+
+// NewTorrentContentTree builds the directory tree of a torrent content from its file names.
+func NewTorrentContentTree(contents []TorrentContent) (root *TorrentContentNode) {
+	root = &TorrentContentNode{}
+	for index := range contents {
+		file := &contents[index]
+		parent := root
+		dirs := strings.Split(file.Name, "/")
+		name := dirs[len(dirs)-1]
+		for _, dir := range dirs[:len(dirs)-1] {
+			parent = parent.directory(dir)
+		}
+		parent.Children = append(parent.Children, &TorrentContentNode{
+			Name:     name,
+			Path:     file.Name,
+			File:     file,
+			Size:     file.Size,
+			Progress: file.Progress,
+			Priority: file.Priority,
+		})
+	}
+	root.aggregate()
+	return
+}
+
+// directory returns the sub directory name, creating it if needed.
+func (tcn *TorrentContentNode) directory(name string) *TorrentContentNode {
+	for _, child := range tcn.Children {
+		if child.File == nil && child.Name == name {
+			return child
+		}
+	}
+	dir := &TorrentContentNode{
+		Name: name,
+		Path: path.Join(tcn.Path, name),
+	}
+	tcn.Children = append(tcn.Children, dir)
+	return dir
+}
+
+// aggregate sorts the directory children and computes its size, progress and priority.
+func (tcn *TorrentContentNode) aggregate() {
+	if tcn.File != nil {
+		return
+	}
+	sort.Slice(tcn.Children, func(i, j int) bool {
+		if (tcn.Children[i].File == nil) != (tcn.Children[j].File == nil) {
+			return tcn.Children[i].File == nil
+		}
+		return tcn.Children[i].Name < tcn.Children[j].Name
+	})
+	for index, child := range tcn.Children {
+		child.aggregate()
+		if index == 0 {
+			tcn.Priority = child.Priority
+		} else if child.Priority != tcn.Priority {
+			tcn.Priority = FilePriorityMixed
+		}
+	}
+	var (
+		wantedSize       int64
+		done, wantedDone float64
+	)
+	tcn.Size = 0
+	tcn.Walk(func(node *TorrentContentNode) {
+		if node.File == nil {
+			return
+		}
+		tcn.Size += node.Size
+		done += node.Progress * float64(node.Size)
+		if node.Priority != FilePriorityDoNotDownload {
+			wantedSize += node.Size
+			wantedDone += node.Progress * float64(node.Size)
+		}
+	})
+	switch {
+	case wantedSize > 0:
+		tcn.Progress = wantedDone / float64(wantedSize)
+	case tcn.Size > 0:
+		tcn.Progress = done / float64(tcn.Size)
+	}
+}
+
+// Walk calls fn for the node and all its descendants, depth first, in children order.
+func (tcn *TorrentContentNode) Walk(fn func(node *TorrentContentNode)) {
+	fn(tcn)
+	for _, child := range tcn.Children {
+		child.Walk(fn)
+	}
+}
+
+// Node returns the node at path (relative to tcn, '/' separated) or nil if it does not exist.
+func (tcn *TorrentContentNode) Node(nodePath string) *TorrentContentNode {
+	if nodePath == "" {
+		return tcn
+	}
+	current := tcn
+	for _, name := range strings.Split(nodePath, "/") {
+		var next *TorrentContentNode
+		for _, child := range current.Children {
+			if child.Name == name {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+// Indexes returns the file indexes of the node: its own or the ones of all the files below it.
+func (tcn *TorrentContentNode) Indexes() (indexes []int) {
+	tcn.Walk(func(node *TorrentContentNode) {
+		if node.File != nil {
+			indexes = append(indexes, node.File.Index)
+		}
+	})
+	return
+}
+
+// TorrentFileSelector selects files of a torrent content.
+type TorrentFileSelector func(file TorrentContent) bool
+
+// SelectFilesByGlob selects the files matching at least one of the patterns (see path.Match()).
+// Patterns without '/' are matched against the file base name, others against the whole file path.
+func SelectFilesByGlob(patterns ...string) TorrentFileSelector {
+	return func(file TorrentContent) bool {
+		for _, pattern := range patterns {
+			name := file.Name
+			if !strings.Contains(pattern, "/") {
+				name = path.Base(name)
+			}
+			if matched, _ := path.Match(pattern, name); matched {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SelectFilesByExtension selects the files having one of the extensions (case insensitive, with or without the leading dot).
+func SelectFilesByExtension(extensions ...string) TorrentFileSelector {
+	normalized := make(map[string]struct{}, len(extensions))
+	for _, extension := range extensions {
+		normalized["."+strings.TrimPrefix(strings.ToLower(extension), ".")] = struct{}{}
+	}
+	return func(file TorrentContent) bool {
+		_, found := normalized[strings.ToLower(path.Ext(file.Name))]
+		return found
+	}
+}
+
+// SelectFilesBySize selects the files whose size (in bytes) is within [minSize, maxSize]. A maxSize of 0 means no upper bound.
+func SelectFilesBySize(minSize, maxSize int64) TorrentFileSelector {
+	return func(file TorrentContent) bool {
+		return file.Size >= minSize && (maxSize == 0 || file.Size <= maxSize)
+	}
+}
+
+var sampleFileRegex = regexp.MustCompile(`(?i)(^|[^\pL\pN])samples?([^\pL\pN]|$)`)
+
+// SelectSampleFiles selects the sample files commonly shipped with releases: files or directories named
+// "sample" or "samples", or containing it as a separate word (eg "movie-sample.mkv").
+func SelectSampleFiles() TorrentFileSelector {
+	return func(file TorrentContent) bool {
+		return sampleFileRegex.MatchString(file.Name)
+	}
+}
+
+// SelectAllFiles selects the files matching all the selectors.
+func SelectAllFiles(selectors ...TorrentFileSelector) TorrentFileSelector {
+	return func(file TorrentContent) bool {
+		for _, selector := range selectors {
+			if !selector(file) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// SelectAnyFiles selects the files matching at least one of the selectors.
+func SelectAnyFiles(selectors ...TorrentFileSelector) TorrentFileSelector {
+	return func(file TorrentContent) bool {
+		for _, selector := range selectors {
+			if selector(file) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SelectOtherFiles selects the files not matched by selector.
+func SelectOtherFiles(selector TorrentFileSelector) TorrentFileSelector {
+	return func(file TorrentContent) bool {
+		return !selector(file)
+	}
+}
+
+// PlanFilePriorities computes the priority of each file with priorityFn and returns the indexes of the files
+// whose priority changes, grouped by new priority (sorted). Each group can be applied with a single
+// SetFilePriority() call, see ApplyFilePriorities().
+func PlanFilePriorities(contents []TorrentContent, priorityFn func(file TorrentContent) FilePriority) (plan map[FilePriority][]int) {
+	plan = make(map[FilePriority][]int)
+	for _, file := range contents {
+		if priority := priorityFn(file); priority != file.Priority {
+			plan[priority] = append(plan[priority], file.Index)
+		}
+	}
+	for _, indexes := range plan {
+		sort.Ints(indexes)
+	}
+	return
+}
+
+// ApplyFilePriorities issues one SetFilePriority() call per priority of plan (see PlanFilePriorities()),
+// in increasing priority order.
+func (c *Client) ApplyFilePriorities(ctx context.Context, hash string, plan map[FilePriority][]int) (err error) {
+	priorities := make([]FilePriority, 0, len(plan))
+	for priority, indexes := range plan {
+		if len(indexes) > 0 {
+			priorities = append(priorities, priority)
+		}
+	}
+	sort.Slice(priorities, func(i, j int) bool { return priorities[i] < priorities[j] })
+	for _, priority := range priorities {
+		if priority == FilePriorityMixed {
+			err = fmt.Errorf("%s is not a valid file priority", priority)
+			return
+		}
+		if err = c.SetFilePriority(ctx, hash, plan[priority], priority); err != nil {
+			err = fmt.Errorf("setting %s priority failed: %w", priority, err)
+			return
+		}
+	}
+	return
+}
+
+// DownloadOnlyFiles only downloads the files of a torrent matched by selector: other files are set to
+// FilePriorityDoNotDownload and selected files not downloaded yet are set to FilePriorityNormal (selected
+// files with a priority keep it). The applied plan is returned (see PlanFilePriorities()).
+func (c *Client) DownloadOnlyFiles(ctx context.Context, hash string, selector TorrentFileSelector) (plan map[FilePriority][]int, err error) {
+	return c.updateFilePriorities(ctx, hash, func(file TorrentContent) FilePriority {
+		switch {
+		case !selector(file):
+			return FilePriorityDoNotDownload
+		case file.Priority == FilePriorityDoNotDownload:
+			return FilePriorityNormal
+		default:
+			return file.Priority
+		}
+	})
+}
+
+// SkipFiles does not download the files of a torrent matched by selector, leaving the other files untouched.
+// For example SkipFiles(ctx, hash, SelectSampleFiles()). The applied plan is returned (see PlanFilePriorities()).
+func (c *Client) SkipFiles(ctx context.Context, hash string, selector TorrentFileSelector) (plan map[FilePriority][]int, err error) {
+	return c.updateFilePriorities(ctx, hash, func(file TorrentContent) FilePriority {
+		if selector(file) {
+			return FilePriorityDoNotDownload
+		}
+		return file.Priority
+	})
+}
+
+func (c *Client) updateFilePriorities(ctx context.Context, hash string, priorityFn func(file TorrentContent) FilePriority) (plan map[FilePriority][]int, err error) {
+	contents, err := c.GetTorrentContents(ctx, hash, nil)
+	if err != nil {
+		err = fmt.Errorf("getting torrent contents failed: %w", err)
+		return
+	}
+	plan = PlanFilePriorities(contents, priorityFn)
+	err = c.ApplyFilePriorities(ctx, hash, plan)
+	return
+}
@@ -0,0 +1,164 @@
+package qbtapi
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func testTorrentContents() []TorrentContent {
+	return []TorrentContent{
+		{Index: 0, Name: "Movie/Movie.mkv", Size: 1000, Progress: 0.5, Priority: FilePriorityNormal},
+		{Index: 1, Name: "Movie/Sample/movie-sample.mkv", Size: 50, Progress: 1, Priority: FilePriorityNormal},
+		{Index: 2, Name: "Movie/Subs/en.srt", Size: 10, Progress: 0, Priority: FilePriorityHigh},
+		{Index: 3, Name: "Movie/Subs/fr.srt", Size: 10, Progress: 0, Priority: FilePriorityDoNotDownload},
+		{Index: 4, Name: "Movie/movie.nfo", Size: 2, Progress: 1, Priority: FilePriorityNormal},
+	}
+}
+
+func TestTorrentContentTree(t *testing.T) {
+	root := NewTorrentContentTree(testTorrentContents())
+	if len(root.Children) != 1 || root.Children[0].Name != "Movie" {
+		t.Fatalf("unexpected root children: %+v", root.Children)
+	}
+	movie := root.Node("Movie")
+	var names []string
+	for _, child := range movie.Children {
+		names = append(names, child.Name)
+	}
+	if strings.Join(names, ",") != "Sample,Subs,Movie.mkv,movie.nfo" {
+		t.Errorf("unexpected children order: %v", names)
+	}
+	if movie.Size != 1072 || movie.Priority != FilePriorityMixed {
+		t.Errorf("unexpected directory aggregation: size %d, priority %s", movie.Size, movie.Priority)
+	}
+	// fr.srt is not downloaded
+	if expected := (500.0 + 50 + 2) / 1062; math.Abs(movie.Progress-expected) > 1e-9 {
+		t.Errorf("expected progress %f, got %f", expected, movie.Progress)
+	}
+	subs := root.Node("Movie/Subs")
+	if subs == nil || subs.Path != "Movie/Subs" || subs.Size != 20 || subs.Priority != FilePriorityMixed {
+		t.Fatalf("unexpected subs node: %+v", subs)
+	}
+	if indexes := subs.Indexes(); len(indexes) != 2 || indexes[0] != 2 || indexes[1] != 3 {
+		t.Errorf("unexpected subs indexes: %v", indexes)
+	}
+	if sample := root.Node("Movie/Sample"); sample.Priority != FilePriorityNormal || sample.Progress != 1 {
+		t.Errorf("unexpected sample node: %+v", sample)
+	}
+	if root.Node("Movie/missing") != nil {
+		t.Error("expected nil for a missing node")
+	}
+}
+
+func TestTorrentFileSelectors(t *testing.T) {
+	contents := testTorrentContents()
+	selected := func(selector TorrentFileSelector) (indexes []int) {
+		for _, file := range contents {
+			if selector(file) {
+				indexes = append(indexes, file.Index)
+			}
+		}
+		return
+	}
+	for name, tc := range map[string]struct {
+		selector TorrentFileSelector
+		expected []int
+	}{
+		"glob base":      {SelectFilesByGlob("*.mkv"), []int{0, 1}},
+		"glob path":      {SelectFilesByGlob("Movie/Subs/*"), []int{2, 3}},
+		"extension":      {SelectFilesByExtension("SRT", ".nfo"), []int{2, 3, 4}},
+		"size":           {SelectFilesBySize(10, 100), []int{1, 2, 3}},
+		"size unbounded": {SelectFilesBySize(100, 0), []int{0}},
+		"samples":        {SelectSampleFiles(), []int{1}},
+		"all":            {SelectAllFiles(SelectFilesByExtension("mkv"), SelectOtherFiles(SelectSampleFiles())), []int{0}},
+		"any":            {SelectAnyFiles(SelectFilesByExtension("nfo"), SelectSampleFiles()), []int{1, 4}},
+	} {
+		if got := selected(tc.selector); !slices.Equal(got, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", name, tc.expected, got)
+		}
+	}
+}
+
+func TestFilePrioritiesBulk(t *testing.T) {
+	// ── fake instance ───────────────────────────────────────
+	var (
+		access   sync.Mutex
+		contents = testTorrentContents()
+		calls    []string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/torrents/files", func(w http.ResponseWriter, r *http.Request) {
+		access.Lock()
+		defer access.Unlock()
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		_ = json.NewEncoder(w).Encode(contents)
+	})
+	mux.HandleFunc("/api/v2/torrents/filePrio", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		priority, _ := strconv.Atoi(r.PostForm.Get("priority"))
+		access.Lock()
+		defer access.Unlock()
+		calls = append(calls, r.PostForm.Get("priority")+":"+r.PostForm.Get("id"))
+		for _, id := range strings.Split(r.PostForm.Get("id"), "|") {
+			index, _ := strconv.Atoi(id)
+			contents[index].Priority = FilePriority(priority)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	endpoint, _ := url.Parse(server.URL)
+	c, err := New(endpoint, "user", "pass")
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	ctx := context.Background()
+
+	// ── skip samples ────────────────────────────────────────
+	plan, err := c.SkipFiles(ctx, "hash", SelectSampleFiles())
+	if err != nil {
+		t.Fatalf("SkipFiles: %v", err)
+	}
+	if len(plan) != 1 || !slices.Equal(plan[FilePriorityDoNotDownload], []int{1}) {
+		t.Errorf("unexpected plan: %v", plan)
+	}
+
+	// ── download only subtitles and mkv ─────────────────────
+	access.Lock()
+	calls = nil
+	access.Unlock()
+	if _, err = c.DownloadOnlyFiles(ctx, "hash", SelectFilesByExtension("srt", "mkv")); err != nil {
+		t.Fatalf("DownloadOnlyFiles: %v", err)
+	}
+	access.Lock()
+	// nfo skipped, sample and fr.srt enabled, others untouched: one call per priority
+	if strings.Join(calls, " ") != "0:4 1:1|3" {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+	if contents[2].Priority != FilePriorityHigh {
+		t.Errorf("existing priority should be kept, got %s", contents[2].Priority)
+	}
+	calls = nil
+	access.Unlock()
+
+	// ── nothing to change ───────────────────────────────────
+	if plan, err = c.DownloadOnlyFiles(ctx, "hash", SelectFilesByExtension("srt", "mkv")); err != nil {
+		t.Fatalf("DownloadOnlyFiles: %v", err)
+	}
+	access.Lock()
+	if len(plan) != 0 || len(calls) != 0 {
+		t.Errorf("expected no changes, got plan %v and calls %v", plan, calls)
+	}
+	access.Unlock()
+	if err = c.ApplyFilePriorities(ctx, "hash", map[FilePriority][]int{FilePriorityMixed: {0}}); err == nil {
+		t.Error("expected an error for the mixed priority")
+	}
+}
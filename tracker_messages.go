@@ -0,0 +1,164 @@
+package qbtapi
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*
+	Tracker messages classification
+*/
+
+// TrackerMessageReason is the meaning of a tracker message, as classified by a TrackerMessageClassifier.
+type TrackerMessageReason uint8
+
+const (
+	TrackerMessageNone             TrackerMessageReason = iota // No message
+	TrackerMessageUnknown                                      // Message not recognized
+	TrackerMessageUnregistered                                 // Torrent unknown to the tracker (deleted, trumped, never uploaded)
+	TrackerMessageInvalidPasskey                               // Passkey or user not recognized by the tracker
+	TrackerMessageRateLimited                                  // Client announcing too often
+	TrackerMessageClientNotAllowed                             // Client (or client version) banned or not whitelisted
+	TrackerMessageMaintenance                                  // Tracker temporarily down or in maintenance
+)
+
+func (tmr TrackerMessageReason) String() string {
+	switch tmr {
+	case TrackerMessageNone:
+		return "None"
+	case TrackerMessageUnknown:
+		return "Unknown"
+	case TrackerMessageUnregistered:
+		return "Unregistered"
+	case TrackerMessageInvalidPasskey:
+		return "InvalidPasskey"
+	case TrackerMessageRateLimited:
+		return "RateLimited"
+	case TrackerMessageClientNotAllowed:
+		return "ClientNotAllowed"
+	case TrackerMessageMaintenance:
+		return "Maintenance"
+	default:
+		return "Unknown"
+	}
+}
+
+// TrackerMessagePattern maps the messages matching Regexp to Reason.
+type TrackerMessagePattern struct {
+	Reason TrackerMessageReason
+	Regexp *regexp.Regexp
+}
+
+// DefaultTrackerMessagePatterns are the patterns of the messages commonly sent by private trackers (matched case insensitively),
+// in evaluation order.
+var DefaultTrackerMessagePatterns = []TrackerMessagePattern{
+	// before unregistered as "passkey not registered" or "user not registered" are about the user, not the torrent
+	{TrackerMessageInvalidPasskey, regexp.MustCompile(`(?i)(invalid|unknown|wrong|bad) (passkey|authkey|torrent_pass|user)|(passkey|authkey|user|account) (is )?(invalid|not found|not registered)|account (disabled|banned)`)},
+	{TrackerMessageUnregistered, regexp.MustCompile(`(?i)unregistered|not registered|torrent (is )?not found|torrent does not exist|unknown torrent|infohash not found|torrent (has been )?(deleted|removed|trumped|nuked)`)},
+	{TrackerMessageRateLimited, regexp.MustCompile(`(?i)too many (requests|announces)|rate limit|announce interval|slow down`)},
+	{TrackerMessageClientNotAllowed, regexp.MustCompile(`(?i)client (is )?(not allowed|banned|blacklisted|not whitelisted)|(unsupported|banned) client`)},
+	{TrackerMessageMaintenance, regexp.MustCompile(`(?i)maintenance|temporarily (down|unavailable)|try again later|tracker (is )?(offline|down)`)},
+}
+
+// TrackerMessageClassifier classifies tracker messages with an ordered set of patterns: the first matching pattern wins.
+// Must be instanciated with NewTrackerMessageClassifier(). Safe for concurrent use.
+type TrackerMessageClassifier struct {
+	access   sync.RWMutex
+	patterns []TrackerMessagePattern
+}
+
+// NewTrackerMessageClassifier returns a classifier using patterns, DefaultTrackerMessagePatterns if none are given.
+func NewTrackerMessageClassifier(patterns ...TrackerMessagePattern) *TrackerMessageClassifier {
+	if len(patterns) == 0 {
+		patterns = DefaultTrackerMessagePatterns
+	}
+	return &TrackerMessageClassifier{
+		patterns: append([]TrackerMessagePattern(nil), patterns...),
+	}
+}
+
+// DefaultTrackerMessageClassifier is the classifier used by TorrentTracker.Reason(). Custom patterns
+// (for example tracker specific messages) can be added to it with Prepend() or Append().
+var DefaultTrackerMessageClassifier = NewTrackerMessageClassifier()
+
+// Prepend adds a pattern (case sensitive unless it starts with "(?i)") taking precedence over the existing ones.
+func (tmc *TrackerMessageClassifier) Prepend(reason TrackerMessageReason, pattern string) (err error) {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		err = fmt.Errorf("compiling pattern failed: %w", err)
+		return
+	}
+	tmc.access.Lock()
+	tmc.patterns = append([]TrackerMessagePattern{{reason, compiled}}, tmc.patterns...)
+	tmc.access.Unlock()
+	return
+}
+
+// Append adds a pattern (case sensitive unless it starts with "(?i)") used when no existing one matches.
+func (tmc *TrackerMessageClassifier) Append(reason TrackerMessageReason, pattern string) (err error) {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		err = fmt.Errorf("compiling pattern failed: %w", err)
+		return
+	}
+	tmc.access.Lock()
+	tmc.patterns = append(tmc.patterns, TrackerMessagePattern{reason, compiled})
+	tmc.access.Unlock()
+	return
+}
+
+// Classify returns the reason of the first pattern matching message, TrackerMessageNone for an empty
+// message and TrackerMessageUnknown if none matches.
+func (tmc *TrackerMessageClassifier) Classify(message string) TrackerMessageReason {
+	if message = strings.TrimSpace(message); message == "" {
+		return TrackerMessageNone
+	}
+	tmc.access.RLock()
+	defer tmc.access.RUnlock()
+	for _, pattern := range tmc.patterns {
+		if pattern.Regexp.MatchString(message) {
+			return pattern.Reason
+		}
+	}
+	return TrackerMessageUnknown
+}
+
+// Reason classifies the tracker message with DefaultTrackerMessageClassifier.
+func (tt TorrentTracker) Reason() TrackerMessageReason {
+	return DefaultTrackerMessageClassifier.Classify(tt.Message)
+}
+
+// GetUnregisteredTorrents returns the torrents for which every tracker (DHT, PeX and LSD excluded) reports
+// a TrackerMessageUnregistered message, sorted by name: they have most likely been removed from their tracker.
+// classifier and options can be nil (DefaultTrackerMessageClassifier is used, DryRun is meaningless here).
+func (c *Client) GetUnregisteredTorrents(ctx context.Context, classifier *TrackerMessageClassifier, options *TrackersOptions) (torrents []TorrentInfos, err error) {
+	if classifier == nil {
+		classifier = DefaultTrackerMessageClassifier
+	}
+	var access sync.Mutex
+	_, err = c.forEachTorrentTrackers(ctx, options, func(torrent TorrentInfos, trackers []TorrentTracker) []TrackerChange {
+		if len(trackers) == 0 {
+			return nil
+		}
+		for _, tracker := range trackers {
+			if classifier.Classify(tracker.Message) != TrackerMessageUnregistered {
+				return nil
+			}
+		}
+		access.Lock()
+		torrents = append(torrents, torrent)
+		access.Unlock()
+		return nil
+	})
+	sort.Slice(torrents, func(i, j int) bool {
+		if torrents[i].Name != torrents[j].Name {
+			return torrents[i].Name < torrents[j].Name
+		}
+		return torrents[i].Hash < torrents[j].Hash
+	})
+	return
+}
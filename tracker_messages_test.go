@@ -0,0 +1,91 @@
+package qbtapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestTrackerMessageClassifier(t *testing.T) {
+	for message, expected := range map[string]TrackerMessageReason{
+		"":                     TrackerMessageNone,
+		"  ":                   TrackerMessageNone,
+		"Unregistered torrent": TrackerMessageUnregistered,
+		"Torrent not found":    TrackerMessageUnregistered,
+		"torrent not registered with this tracker": TrackerMessageUnregistered,
+		"Torrent has been deleted.":                TrackerMessageUnregistered,
+		"Invalid passkey":                          TrackerMessageInvalidPasskey,
+		"passkey not found":                        TrackerMessageInvalidPasskey,
+		"passkey not registered":                   TrackerMessageInvalidPasskey,
+		"User not registered":                      TrackerMessageInvalidPasskey,
+		"user not found":                           TrackerMessageInvalidPasskey,
+		"Too many requests":                        TrackerMessageRateLimited,
+		"Your client is not whitelisted":           TrackerMessageClientNotAllowed,
+		"Tracker is down for maintenance":          TrackerMessageMaintenance,
+		"skipping tracker announce (unreachable)":  TrackerMessageUnknown,
+	} {
+		if reason := DefaultTrackerMessageClassifier.Classify(message); reason != expected {
+			t.Errorf("Classify(%q): expected %s, got %s", message, expected, reason)
+		}
+	}
+	if reason := (TorrentTracker{Message: "unregistered torrent"}).Reason(); reason != TrackerMessageUnregistered {
+		t.Errorf("TorrentTracker.Reason: expected %s, got %s", TrackerMessageUnregistered, reason)
+	}
+
+	// ── custom patterns ──────────────────────────────────────
+	classifier := NewTrackerMessageClassifier()
+	if err := classifier.Append(TrackerMessageUnregistered, `(?i)^gone$`); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := classifier.Prepend(TrackerMessageMaintenance, `Unregistered torrent \(upgrade\)`); err != nil {
+		t.Fatalf("Prepend: %v", err)
+	}
+	if err := classifier.Append(TrackerMessageUnregistered, `(`); err == nil {
+		t.Error("Append: expected an error for an invalid pattern")
+	}
+	if reason := classifier.Classify("GONE"); reason != TrackerMessageUnregistered {
+		t.Errorf("Classify: appended pattern: got %s", reason)
+	}
+	if reason := classifier.Classify("Unregistered torrent (upgrade)"); reason != TrackerMessageMaintenance {
+		t.Errorf("Classify: prepended pattern: got %s", reason)
+	}
+	if reason := DefaultTrackerMessageClassifier.Classify("GONE"); reason != TrackerMessageUnknown {
+		t.Errorf("Classify: default classifier altered: got %s", reason)
+	}
+}
+
+func TestGetUnregisteredTorrents(t *testing.T) {
+	trackers := map[string]string{
+		"aaaa": `[{"url":"** [DHT] **","status":0,"tier":-1},{"url":"https://a.example.org/announce","status":4,"tier":0,"msg":"Unregistered torrent"},{"url":"https://b.example.org/announce","status":4,"tier":1,"msg":"torrent not found"}]`,
+		"bbbb": `[{"url":"https://a.example.org/announce","status":4,"tier":0,"msg":"Unregistered torrent"},{"url":"https://b.example.org/announce","status":2,"tier":1}]`,
+		"cccc": `[{"url":"** [DHT] **","status":0,"tier":-1}]`,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/torrents/info", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		_, _ = w.Write([]byte(`[{"hash":"aaaa","name":"gone"},{"hash":"bbbb","name":"partially gone"},{"hash":"cccc","name":"trackerless"}]`))
+	})
+	mux.HandleFunc("/api/v2/torrents/trackers", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		_, _ = w.Write([]byte(trackers[r.URL.Query().Get("hash")]))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("parsing test server URL: %v", err)
+	}
+	client, err := New(endpoint, "user", "pass")
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	torrents, err := client.GetUnregisteredTorrents(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("GetUnregisteredTorrents: %v", err)
+	}
+	if len(torrents) != 1 || torrents[0].Hash != "aaaa" {
+		t.Errorf("GetUnregisteredTorrents: unexpected torrents %+v", torrents)
+	}
+}
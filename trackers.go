@@ -0,0 +1,440 @@
+package qbtapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+	Trackers management across torrents
+*/
+
+// DefaultTrackersConcurrency is the number of torrents processed concurrently by the trackers operations if not specified
+const DefaultTrackersConcurrency = 8
+
+// TrackersOptions holds the options of the trackers operations. All fields are optional.
+type TrackersOptions struct {
+	Filters     *ListFilters // Torrents to process, all torrents if nil
+	Concurrency int          // Number of torrents processed concurrently, default is DefaultTrackersConcurrency
+	DryRun      bool         // Only compute the changes
+}
+
+// TrackerChange describes a tracker change on a torrent.
+type TrackerChange struct {
+	Hash   string // Torrent hash
+	Name   string // Torrent name
+	OldURL string // Empty if the tracker is added
+	NewURL string // Empty if the tracker is removed
+}
+
+// realTracker returns true if the entry is an actual tracker and not a DHT, PeX or LSD placeholder.
+func realTracker(tracker TorrentTracker) bool {
+	return tracker.URL != nil && tracker.URL.Scheme != "" && tracker.Tier >= 0
+}
+
+// forEachTorrentTrackers lists the torrents matching options and calls fn for each torrent with its trackers
+// (placeholders excluded), concurrently. Changes returned by fn are applied unless in dry run mode.
+// The applied changes (all of them in dry run mode) are returned, sorted by torrent name and hash; failed ones are
+// reported in err and not returned.
+func (c *Client) forEachTorrentTrackers(ctx context.Context, options *TrackersOptions,
+	fn func(torrent TorrentInfos, trackers []TorrentTracker) []TrackerChange) (changes []TrackerChange, err error) {
+	if options == nil {
+		options = &TrackersOptions{}
+	}
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultTrackersConcurrency
+	}
+	torrents, err := c.GetTorrentList(ctx, options.Filters)
+	if err != nil {
+		err = fmt.Errorf("listing torrents failed: %w", err)
+		return
+	}
+	var (
+		work    = make(chan TorrentInfos)
+		workers sync.WaitGroup
+		access  sync.Mutex
+		errs    []error
+	)
+	for range concurrency {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for torrent := range work {
+				torrentChanges, torrentErr := c.processTorrentTrackers(ctx, torrent, options.DryRun, fn)
+				access.Lock()
+				changes = append(changes, torrentChanges...)
+				if torrentErr != nil {
+					errs = append(errs, fmt.Errorf("torrent %s (%s): %w", torrent.Hash, torrent.Name, torrentErr))
+				}
+				access.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, torrent := range torrents {
+		select {
+		case work <- torrent:
+		case <-ctx.Done():
+			access.Lock()
+			errs = append(errs, ctx.Err())
+			access.Unlock()
+			break feed
+		}
+	}
+	close(work)
+	workers.Wait()
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Name != changes[j].Name {
+			return changes[i].Name < changes[j].Name
+		}
+		return changes[i].Hash < changes[j].Hash
+	})
+	err = errors.Join(errs...)
+	return
+}
+
+// processTorrentTrackers computes the changes of a torrent with fn and applies them, returning the applied ones.
+func (c *Client) processTorrentTrackers(ctx context.Context, torrent TorrentInfos, dryRun bool,
+	fn func(torrent TorrentInfos, trackers []TorrentTracker) []TrackerChange) (changes []TrackerChange, err error) {
+	allTrackers, err := c.GetTorrentTrackers(ctx, torrent.Hash)
+	if err != nil {
+		err = fmt.Errorf("getting trackers failed: %w", err)
+		return
+	}
+	trackers := make([]TorrentTracker, 0, len(allTrackers))
+	for _, tracker := range allTrackers {
+		if realTracker(tracker) {
+			trackers = append(trackers, tracker)
+		}
+	}
+	planned := fn(torrent, trackers)
+	if dryRun || len(planned) == 0 {
+		changes = planned
+		return
+	}
+	// apply: edits one by one, additions and removals in a single call each
+	var additions, removals []TrackerChange
+	for _, change := range planned {
+		switch {
+		case change.OldURL == "":
+			additions = append(additions, change)
+		case change.NewURL == "":
+			removals = append(removals, change)
+		default:
+			if err = c.EditTracker(ctx, torrent.Hash, change.OldURL, change.NewURL); err != nil {
+				err = fmt.Errorf("editing tracker %q failed: %w", change.OldURL, err)
+				return
+			}
+			changes = append(changes, change)
+		}
+	}
+	if len(additions) > 0 {
+		added := make([]string, len(additions))
+		for index, change := range additions {
+			added[index] = change.NewURL
+		}
+		if err = c.AddTrackers(ctx, torrent.Hash, added); err != nil {
+			err = fmt.Errorf("adding trackers failed: %w", err)
+			return
+		}
+		changes = append(changes, additions...)
+	}
+	if len(removals) > 0 {
+		removed := make([]string, len(removals))
+		for index, change := range removals {
+			removed[index] = change.OldURL
+		}
+		if err = c.RemoveTrackers(ctx, torrent.Hash, removed); err != nil {
+			err = fmt.Errorf("removing trackers failed: %w", err)
+			return
+		}
+		changes = append(changes, removals...)
+	}
+	return
+}
+
+// RewriteTrackers calls rewrite for each tracker of the torrents and replaces the trackers for which it
+// returns a new URL (nil keeps the tracker), for example to change a passkey or a tracker domain.
+// options can be nil. See TrackersOptions for dry run and concurrency.
+func (c *Client) RewriteTrackers(ctx context.Context, rewrite func(tracker *url.URL) *url.URL, options *TrackersOptions) (changes []TrackerChange, err error) {
+	return c.forEachTorrentTrackers(ctx, options, func(torrent TorrentInfos, trackers []TorrentTracker) (torrentChanges []TrackerChange) {
+		for _, tracker := range trackers {
+			trackerURL := *tracker.URL
+			if newURL := rewrite(&trackerURL); newURL != nil && newURL.String() != tracker.URL.String() {
+				torrentChanges = append(torrentChanges, TrackerChange{
+					Hash:   torrent.Hash,
+					Name:   torrent.Name,
+					OldURL: tracker.URL.String(),
+					NewURL: newURL.String(),
+				})
+			}
+		}
+		return
+	})
+}
+
+// ReplaceTrackerURL replaces the oldURL tracker by newURL on all the torrents using it. options can be nil.
+// URLs are compared once normalized (see normalizeTrackerURL()): case of the scheme and host, default port and
+// escaping do not matter.
+func (c *Client) ReplaceTrackerURL(ctx context.Context, oldURL, newURL string, options *TrackersOptions) (changes []TrackerChange, err error) {
+	original, err := url.Parse(oldURL)
+	if err != nil {
+		err = fmt.Errorf("parsing old URL failed: %w", err)
+		return
+	}
+	replacement, err := url.Parse(newURL)
+	if err != nil {
+		err = fmt.Errorf("parsing new URL failed: %w", err)
+		return
+	}
+	normalized := normalizeTrackerURL(original)
+	return c.RewriteTrackers(ctx, func(tracker *url.URL) *url.URL {
+		if normalizeTrackerURL(tracker) == normalized {
+			return replacement
+		}
+		return nil
+	}, options)
+}
+
+// normalizeTrackerURL returns the canonical form of a tracker URL: lower case scheme and host, no default port
+// and canonical escaping of the path and query.
+func normalizeTrackerURL(tracker *url.URL) string {
+	normalized := *tracker
+	normalized.Scheme = strings.ToLower(normalized.Scheme)
+	host, port := strings.ToLower(normalized.Hostname()), normalized.Port()
+	if (normalized.Scheme == "http" && port == "80") || (normalized.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	normalized.Host = host
+	normalized.RawPath = ""
+	if normalized.RawQuery != "" {
+		normalized.RawQuery = normalized.Query().Encode()
+	}
+	return normalized.String()
+}
+
+// AddPublicTrackers adds the trackers of urls missing on public torrents, private torrents being left untouched
+// as well as torrents whose metadata is not downloaded yet (their private flag is unknown).
+// As it relies on the private flag of torrents listings, qBittorrent 5 or later is required. options can be nil.
+func (c *Client) AddPublicTrackers(ctx context.Context, urls []string, options *TrackersOptions) (changes []TrackerChange, err error) {
+	supported, err := c.Supports(ctx, APIFeatureTorrentPrivateFlag)
+	if err != nil {
+		return
+	}
+	if !supported {
+		err = errors.New("the server does not report the torrents private flag: refusing to add trackers")
+		return
+	}
+	return c.forEachTorrentTrackers(ctx, options, func(torrent TorrentInfos, trackers []TorrentTracker) (torrentChanges []TrackerChange) {
+		if torrent.Private || torrent.PrivateUnknown {
+			// torrents without metadata yet might be private
+			return
+		}
+		existing := make(map[string]struct{}, len(trackers))
+		for _, tracker := range trackers {
+			existing[tracker.URL.String()] = struct{}{}
+		}
+		for _, trackerURL := range urls {
+			if _, found := existing[trackerURL]; found {
+				continue
+			}
+			existing[trackerURL] = struct{}{}
+			torrentChanges = append(torrentChanges, TrackerChange{
+				Hash:   torrent.Hash,
+				Name:   torrent.Name,
+				NewURL: trackerURL,
+			})
+		}
+		return
+	})
+}
+
+// DeadTrackersWatcher remembers since when trackers have been seen not working, as the API does not report it.
+// Must be instanciated with NewDeadTrackersWatcher() and kept between RemoveDeadTrackers() calls.
+type DeadTrackersWatcher struct {
+	access          sync.Mutex
+	notWorkingSince map[[2]string]time.Time // [hash, tracker URL] -> first time seen not working
+	now             func() time.Time
+}
+
+// NewDeadTrackersWatcher returns a watcher without any observation.
+func NewDeadTrackersWatcher() *DeadTrackersWatcher {
+	return &DeadTrackersWatcher{
+		notWorkingSince: make(map[[2]string]time.Time),
+		now:             time.Now,
+	}
+}
+
+// observe records the trackers states of a torrent and returns the trackers not working for at least minDowntime.
+func (dtw *DeadTrackersWatcher) observe(hash string, trackers []TorrentTracker, minDowntime time.Duration) (dead []TorrentTracker) {
+	dtw.access.Lock()
+	defer dtw.access.Unlock()
+	now := dtw.now()
+	seen := make(map[[2]string]struct{}, len(trackers))
+	for _, tracker := range trackers {
+		key := [2]string{hash, tracker.URL.String()}
+		seen[key] = struct{}{}
+		if tracker.Status != TorrentTrackerNotWorking {
+			delete(dtw.notWorkingSince, key)
+			continue
+		}
+		since, found := dtw.notWorkingSince[key]
+		if !found {
+			since = now
+			dtw.notWorkingSince[key] = now
+		}
+		if now.Sub(since) >= minDowntime {
+			dead = append(dead, tracker)
+		}
+	}
+	// forget the trackers removed since the last observation
+	for key := range dtw.notWorkingSince {
+		if _, found := seen[key]; !found && key[0] == hash {
+			delete(dtw.notWorkingSince, key)
+		}
+	}
+	return
+}
+
+// prune forgets the torrents not in listed (deleted or filtered out since their last observation).
+func (dtw *DeadTrackersWatcher) prune(listed map[string]struct{}) {
+	dtw.access.Lock()
+	defer dtw.access.Unlock()
+	for key := range dtw.notWorkingSince {
+		if _, found := listed[key[0]]; !found {
+			delete(dtw.notWorkingSince, key)
+		}
+	}
+}
+
+// RemoveDeadTrackers removes the trackers seen not working by watcher for at least minDowntime. The states of the
+// trackers are recorded at each call: call it periodically with the same watcher. A torrent always keeps at least
+// one tracker. After a call without errors, the watcher forgets the torrents which were not listed (deleted or
+// filtered out by options). options can be nil.
+func (c *Client) RemoveDeadTrackers(ctx context.Context, watcher *DeadTrackersWatcher, minDowntime time.Duration, options *TrackersOptions) (changes []TrackerChange, err error) {
+	var listedAccess sync.Mutex
+	listed := make(map[string]struct{})
+	changes, err = c.forEachTorrentTrackers(ctx, options, func(torrent TorrentInfos, trackers []TorrentTracker) (torrentChanges []TrackerChange) {
+		listedAccess.Lock()
+		listed[torrent.Hash] = struct{}{}
+		listedAccess.Unlock()
+		dead := watcher.observe(torrent.Hash, trackers, minDowntime)
+		if len(dead) == len(trackers) && len(dead) > 0 {
+			dead = dead[:len(dead)-1]
+		}
+		for _, tracker := range dead {
+			torrentChanges = append(torrentChanges, TrackerChange{
+				Hash:   torrent.Hash,
+				Name:   torrent.Name,
+				OldURL: tracker.URL.String(),
+			})
+		}
+		return
+	})
+	if err == nil {
+		// all listed torrents have been observed
+		watcher.prune(listed)
+	}
+	return
+}
+
+// TrackerHealth aggregates the states of a tracker (identified by its scheme and host) over all torrents.
+type TrackerHealth struct {
+	Tracker  string                       // Tracker scheme and host (eg "udp://tracker.example.org:1337")
+	Torrents int                          // Number of torrents using it
+	Statuses map[TorrentTrackerStatus]int // Number of torrents per tracker status
+	Messages map[string]int               // Number of torrents per tracker message (empty messages excluded)
+}
+
+// Working returns the ratio of torrents for which the tracker is working.
+func (th TrackerHealth) Working() float64 {
+	if th.Torrents == 0 {
+		return 0
+	}
+	return float64(th.Statuses[TorrentTrackerWorking]) / float64(th.Torrents)
+}
+
+// trackerStatusRank orders the tracker statuses from the worst to the best.
+func trackerStatusRank(status TorrentTrackerStatus) int {
+	switch status {
+	case TorrentTrackerWorking:
+		return 4
+	case TorrentTrackerUpdating:
+		return 3
+	case TorrentTrackerNotContacted:
+		return 2
+	case TorrentTrackerNotWorking:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// GetTrackersHealth returns the health of each tracker aggregated over the torrents, sorted by tracker.
+// Trackers are identified by their scheme and host so passkeys do not split them: a torrent using several URLs
+// of the same tracker is counted once, with the best of their statuses. options can be nil
+// (DryRun is meaningless here).
+func (c *Client) GetTrackersHealth(ctx context.Context, options *TrackersOptions) (health []TrackerHealth, err error) {
+	var access sync.Mutex
+	byTracker := make(map[string]*TrackerHealth)
+	_, err = c.forEachTorrentTrackers(ctx, options, func(torrent TorrentInfos, trackers []TorrentTracker) []TrackerChange {
+		// a torrent can use several URLs of the same tracker: count it once per tracker, with its best status
+		type torrentHealth struct {
+			status   TorrentTrackerStatus
+			messages map[string]struct{}
+		}
+		byTorrentTracker := make(map[string]*torrentHealth)
+		for _, tracker := range trackers {
+			key := tracker.URL.Scheme + "://" + tracker.URL.Host
+			current := byTorrentTracker[key]
+			if current == nil {
+				current = &torrentHealth{
+					status:   tracker.Status,
+					messages: make(map[string]struct{}),
+				}
+				byTorrentTracker[key] = current
+			} else if trackerStatusRank(tracker.Status) > trackerStatusRank(current.status) {
+				current.status = tracker.Status
+			}
+			if tracker.Message != "" {
+				current.messages[tracker.Message] = struct{}{}
+			}
+		}
+		access.Lock()
+		defer access.Unlock()
+		for key, current := range byTorrentTracker {
+			trackerHealth := byTracker[key]
+			if trackerHealth == nil {
+				trackerHealth = &TrackerHealth{
+					Tracker:  key,
+					Statuses: make(map[TorrentTrackerStatus]int),
+					Messages: make(map[string]int),
+				}
+				byTracker[key] = trackerHealth
+			}
+			trackerHealth.Torrents++
+			trackerHealth.Statuses[current.status]++
+			for message := range current.messages {
+				trackerHealth.Messages[message]++
+			}
+		}
+		return nil
+	})
+	for _, key := range sortedKeys(byTracker) {
+		health = append(health, *byTracker[key])
+	}
+	return
+}
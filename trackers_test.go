@@ -0,0 +1,224 @@
+package qbtapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTrackers(t *testing.T) {
+	ctx := context.Background()
+
+	// ── fake instance ──────────────────────────────────────
+	var (
+		access   sync.Mutex
+		version  = APIReferenceVersion
+		trackers = map[string][]string{ // hash -> tracker entries
+			"aaaa": {
+				`{"url":"** [DHT] **","status":2,"tier":-1}`,
+				`{"url":"https://tracker.example.org/announce?passkey=old","status":2,"tier":0}`,
+				`{"url":"udp://dead.example.org:1337/announce","status":4,"tier":1,"msg":"timed out"}`,
+			},
+			"bbbb": {
+				`{"url":"udp://dead.example.org:1337/announce","status":4,"tier":0,"msg":"timed out"}`,
+			},
+		}
+		calls   []string
+		failing string // method answering with an error
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/app/webapiVersion", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeHeader, contentTypeHeaderTextPlain)
+		access.Lock()
+		defer access.Unlock()
+		_, _ = w.Write([]byte(version))
+	})
+	mux.HandleFunc("/api/v2/torrents/info", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		_, _ = w.Write([]byte(`[{"hash":"aaaa","name":"public","is_private":false},{"hash":"bbbb","name":"private","is_private":true},{"hash":"cccc","name":"magnet","state":"metaDL","is_private":null}]`))
+	})
+	mux.HandleFunc("/api/v2/torrents/trackers", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		access.Lock()
+		defer access.Unlock()
+		_, _ = w.Write([]byte("[" + strings.Join(trackers[r.URL.Query().Get("hash")], ",") + "]"))
+	})
+	for _, method := range []string{"addTrackers", "editTracker", "removeTrackers"} {
+		mux.HandleFunc("/api/v2/torrents/"+method, func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			access.Lock()
+			defer access.Unlock()
+			if method == failing {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			call := method + " " + r.PostForm.Get("hash")
+			for _, field := range []string{"origUrl", "newUrl", "urls"} {
+				if value := r.PostForm.Get(field); value != "" {
+					call += " " + value
+				}
+			}
+			calls = append(calls, call)
+		})
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("parsing test server URL: %v", err)
+	}
+	newClient := func() *Client {
+		t.Helper()
+		client, err := New(endpoint, "user", "pass")
+		if err != nil {
+			t.Fatalf("creating client: %v", err)
+		}
+		return client
+	}
+	client := newClient()
+	takeCalls := func() []string {
+		access.Lock()
+		defer access.Unlock()
+		taken := calls
+		calls = nil
+		return taken
+	}
+
+	// ── replace ──────────────────────────────────────
+	changes, err := client.RewriteTrackers(ctx, func(tracker *url.URL) *url.URL {
+		if tracker.Host != "tracker.example.org" {
+			return nil
+		}
+		query := tracker.Query()
+		query.Set("passkey", "new")
+		tracker.RawQuery = query.Encode()
+		return tracker
+	}, &TrackersOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("RewriteTrackers: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Hash != "aaaa" || changes[0].NewURL != "https://tracker.example.org/announce?passkey=new" {
+		t.Fatalf("RewriteTrackers: unexpected changes %+v", changes)
+	}
+	if taken := takeCalls(); len(taken) != 0 {
+		t.Errorf("RewriteTrackers: dry run applied changes: %v", taken)
+	}
+	if _, err = client.ReplaceTrackerURL(ctx, "UDP://Dead.Example.org:1337/%61nnounce", "udp://alive.example.org:1337/announce", nil); err != nil {
+		t.Fatalf("ReplaceTrackerURL: %v", err)
+	}
+	taken := takeCalls()
+	if len(taken) != 2 || !strings.HasPrefix(taken[0], "editTracker ") || !strings.HasSuffix(taken[0], " udp://dead.example.org:1337/announce udp://alive.example.org:1337/announce") {
+		t.Errorf("ReplaceTrackerURL: unexpected calls %v", taken)
+	}
+
+	for _, equivalent := range [][2]string{
+		{"https://tracker.example.org:443/announce?passkey=old", "HTTPS://Tracker.Example.org/announce?passkey=old"},
+		{"http://[::1]:80/a%20b", "http://[::1]/a b"},
+	} {
+		first, _ := url.Parse(equivalent[0])
+		second, _ := url.Parse(equivalent[1])
+		if normalizeTrackerURL(first) != normalizeTrackerURL(second) {
+			t.Errorf("normalizeTrackerURL: %q and %q differ: %q != %q", equivalent[0], equivalent[1], normalizeTrackerURL(first), normalizeTrackerURL(second))
+		}
+	}
+
+	// ── public trackers ──────────────────────────────────────
+	var torrent TorrentInfos
+	if err = json.Unmarshal([]byte(`{"hash":"cccc","is_private":null}`), &torrent); err != nil || torrent.Private || !torrent.PrivateUnknown {
+		t.Errorf("unknown private flag: got %+v (%v)", torrent, err)
+	}
+	if err = json.Unmarshal([]byte(`{"hash":"bbbb","is_private":true}`), &torrent); err != nil || !torrent.Private || torrent.PrivateUnknown {
+		t.Errorf("private flag: got %+v (%v)", torrent, err)
+	}
+	changes, err = client.AddPublicTrackers(ctx, []string{
+		"https://tracker.example.org/announce?passkey=old",
+		"udp://open.example.org:6969/announce",
+	}, nil)
+	if err != nil {
+		t.Fatalf("AddPublicTrackers: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Hash != "aaaa" || changes[0].NewURL != "udp://open.example.org:6969/announce" {
+		t.Errorf("AddPublicTrackers: unexpected changes %+v", changes)
+	}
+	if taken := takeCalls(); len(taken) != 1 || taken[0] != "addTrackers aaaa udp://open.example.org:6969/announce" {
+		t.Errorf("AddPublicTrackers: unexpected calls %v", taken)
+	}
+	access.Lock()
+	failing = "addTrackers"
+	access.Unlock()
+	if changes, err = client.AddPublicTrackers(ctx, []string{"udp://other.example.org:6969/announce"}, nil); err == nil || len(changes) != 0 {
+		t.Errorf("AddPublicTrackers: expected an error and no applied changes, got %+v (%v)", changes, err)
+	}
+	access.Lock()
+	failing = ""
+	version = "2.10.4"
+	access.Unlock()
+	if _, err = newClient().AddPublicTrackers(ctx, []string{"udp://open.example.org:6969/announce"}, nil); err == nil {
+		t.Error("AddPublicTrackers: expected an error without private flag support")
+	}
+	if taken := takeCalls(); len(taken) != 0 {
+		t.Errorf("AddPublicTrackers: unexpected calls without private flag support %v", taken)
+	}
+
+	// ── dead trackers ──────────────────────────────────────
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	watcher := NewDeadTrackersWatcher()
+	watcher.now = func() time.Time { return now }
+	watcher.notWorkingSince[[2]string{"dddd", "udp://dead.example.org:1337/announce"}] = now // deleted torrent
+	if changes, err = client.RemoveDeadTrackers(ctx, watcher, time.Hour, nil); err != nil || len(changes) != 0 {
+		t.Fatalf("RemoveDeadTrackers: first observation: %+v, %v", changes, err)
+	}
+	if _, found := watcher.notWorkingSince[[2]string{"dddd", "udp://dead.example.org:1337/announce"}]; found {
+		t.Error("RemoveDeadTrackers: deleted torrent not forgotten")
+	}
+	now = now.Add(2 * time.Hour)
+	if changes, err = client.RemoveDeadTrackers(ctx, watcher, time.Hour, nil); err != nil {
+		t.Fatalf("RemoveDeadTrackers: %v", err)
+	}
+	// bbbb only has the dead tracker: it must keep it
+	if len(changes) != 1 || changes[0].Hash != "aaaa" || changes[0].OldURL != "udp://dead.example.org:1337/announce" {
+		t.Errorf("RemoveDeadTrackers: unexpected changes %+v", changes)
+	}
+	if taken := takeCalls(); len(taken) != 1 || taken[0] != "removeTrackers aaaa udp://dead.example.org:1337/announce" {
+		t.Errorf("RemoveDeadTrackers: unexpected calls %v", taken)
+	}
+	// a tracker working again resets its downtime
+	access.Lock()
+	trackers["aaaa"][2] = strings.Replace(trackers["aaaa"][2], `"status":4`, `"status":2`, 1)
+	access.Unlock()
+	if _, err = client.RemoveDeadTrackers(ctx, watcher, time.Hour, &TrackersOptions{DryRun: true}); err != nil {
+		t.Fatalf("RemoveDeadTrackers: %v", err)
+	}
+	if _, found := watcher.notWorkingSince[[2]string{"aaaa", "udp://dead.example.org:1337/announce"}]; found {
+		t.Error("RemoveDeadTrackers: working tracker still considered not working")
+	}
+
+	// ── health ──────────────────────────────────────
+	// a second URL of the same tracker must not count the torrent twice
+	access.Lock()
+	trackers["aaaa"] = append(trackers["aaaa"], `{"url":"https://tracker.example.org/backup?passkey=old","status":4,"tier":2,"msg":"timed out"}`)
+	access.Unlock()
+	health, err := client.GetTrackersHealth(ctx, nil)
+	if err != nil {
+		t.Fatalf("GetTrackersHealth: %v", err)
+	}
+	if len(health) != 2 || health[0].Tracker != "https://tracker.example.org" || health[1].Tracker != "udp://dead.example.org:1337" {
+		t.Fatalf("GetTrackersHealth: unexpected trackers %+v", health)
+	}
+	if dead := health[1]; dead.Torrents != 2 || dead.Statuses[TorrentTrackerNotWorking] != 1 ||
+		dead.Messages["timed out"] != 2 || dead.Working() != 0.5 {
+		t.Errorf("GetTrackersHealth: unexpected dead tracker health %+v", dead)
+	}
+	if shared := health[0]; shared.Torrents != 1 || shared.Statuses[TorrentTrackerWorking] != 1 || shared.Messages["timed out"] != 1 {
+		t.Errorf("GetTrackersHealth: unexpected shared tracker health %+v", shared)
+	}
+}
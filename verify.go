@@ -0,0 +1,256 @@
+package qbtapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+/*
+	Local data verification
+*/
+
+// PieceVerifyOptions holds the options of VerifyPieces() and VerifyTorrentData(). All fields are optional.
+type PieceVerifyOptions struct {
+	Concurrency int                       // Number of pieces hashed concurrently, default is runtime.NumCPU()
+	Progress    func(verified, total int) // Called (from any goroutine, never concurrently) after each verified piece
+}
+
+// PieceVerification is the outcome of a local data verification.
+type PieceVerification struct {
+	Pieces        int                // Number of pieces verified
+	CorruptPieces []int              // Pieces whose data does not match their hash
+	MissingPieces []int              // Pieces whose data could not be read entirely (missing or truncated files)
+	AffectedFiles []FileVerification // Files containing corrupt or missing pieces, in index order
+}
+
+// OK returns true if all the pieces have been read and match their hash.
+func (pv PieceVerification) OK() bool {
+	return len(pv.CorruptPieces) == 0 && len(pv.MissingPieces) == 0
+}
+
+// FileVerification reports the bad pieces of a file (pieces shared with other files included).
+type FileVerification struct {
+	File          TorrentContent
+	CorruptPieces int
+	MissingPieces int
+}
+
+type pieceVerifyResult uint8
+
+const (
+	pieceVerifyOK pieceVerifyResult = iota
+	pieceVerifyCorrupt
+	pieceVerifyMissing
+)
+
+// verifiedFile is a torrent file with its offset within the torrent data.
+type verifiedFile struct {
+	content TorrentContent
+	offset  int64
+}
+
+// VerifyTorrentData verifies the data of a torrent found in fsys (typically os.DirFS() of a local mount of the
+// torrent save path) against its piece hashes, without making qBittorrent recheck it. options can be nil.
+// See VerifyPieces().
+func (c *Client) VerifyTorrentData(ctx context.Context, hash string, fsys fs.FS, options *PieceVerifyOptions) (report PieceVerification, err error) {
+	properties, err := c.GetTorrentGenericProperties(ctx, hash)
+	if err != nil {
+		err = fmt.Errorf("getting torrent properties failed: %w", err)
+		return
+	}
+	contents, err := c.GetTorrentContents(ctx, hash, nil)
+	if err != nil {
+		err = fmt.Errorf("getting torrent contents failed: %w", err)
+		return
+	}
+	hashes, err := c.GetTorrentPiecesHashes(ctx, hash)
+	if err != nil {
+		err = fmt.Errorf("getting torrent pieces hashes failed: %w", err)
+		return
+	}
+	return VerifyPieces(ctx, fsys, contents, int64(properties.PieceSize.Bytes()), hashes, options)
+}
+
+// VerifyPieces reads the files of a torrent from fsys (files being opened with their content name) and checks
+// each piece against its SHA-1 hash, concurrently. options can be nil. Only v1 (and hybrid torrents without
+// padding files) are supported: the files laid out in index order must match the number of pieces.
+// Unreadable pieces are reported as missing: an error is only returned if the verification could not be done.
+func VerifyPieces(ctx context.Context, fsys fs.FS, contents []TorrentContent, pieceSize int64, hashes []string, options *PieceVerifyOptions) (report PieceVerification, err error) {
+	// lay out files and check consistency
+	if pieceSize <= 0 {
+		err = fmt.Errorf("invalid piece size: %d", pieceSize)
+		return
+	}
+	files := make([]verifiedFile, len(contents))
+	for index, content := range contents {
+		files[index].content = content
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].content.Index < files[j].content.Index })
+	var totalSize int64
+	for index := range files {
+		files[index].offset = totalSize
+		totalSize += files[index].content.Size
+	}
+	if expected := int((totalSize + pieceSize - 1) / pieceSize); expected != len(hashes) {
+		err = fmt.Errorf("files total size needs %d pieces but %d hashes were provided (torrent with padding files?)", expected, len(hashes))
+		return
+	}
+	expectedHashes := make([][]byte, len(hashes))
+	for index, pieceHash := range hashes {
+		if expectedHashes[index], err = hex.DecodeString(pieceHash); err != nil || len(expectedHashes[index]) != sha1.Size {
+			err = fmt.Errorf("piece %d hash %q is not a SHA-1 hash: only v1 piece hashes are supported", index, pieceHash)
+			return
+		}
+	}
+	concurrency := runtime.NumCPU()
+	var progress func(verified, total int)
+	if options != nil {
+		if options.Concurrency > 0 {
+			concurrency = options.Concurrency
+		}
+		progress = options.Progress
+	}
+	// verify pieces
+	var (
+		results        = make([]pieceVerifyResult, len(hashes))
+		pieces         = make(chan int)
+		workers        sync.WaitGroup
+		progressAccess sync.Mutex
+		verified       int
+	)
+	for range concurrency {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			buffer := make([]byte, pieceSize)
+			for piece := range pieces {
+				start := int64(piece) * pieceSize
+				data := buffer[:min(pieceSize, totalSize-start)]
+				switch readErr := readPiece(fsys, files, start, data); {
+				case readErr != nil:
+					results[piece] = pieceVerifyMissing
+				case !bytes.Equal(sha1Sum(data), expectedHashes[piece]):
+					results[piece] = pieceVerifyCorrupt
+				}
+				if progress != nil {
+					progressAccess.Lock()
+					verified++
+					progress(verified, len(hashes))
+					progressAccess.Unlock()
+				}
+			}
+		}()
+	}
+feed:
+	for piece := range hashes {
+		select {
+		case pieces <- piece:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(pieces)
+	workers.Wait()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	// build report
+	report.Pieces = len(hashes)
+	affected := make(map[int]*FileVerification)
+	for piece, result := range results {
+		if result == pieceVerifyOK {
+			continue
+		}
+		if result == pieceVerifyCorrupt {
+			report.CorruptPieces = append(report.CorruptPieces, piece)
+		} else {
+			report.MissingPieces = append(report.MissingPieces, piece)
+		}
+		start := int64(piece) * pieceSize
+		for _, file := range pieceFiles(files, start, min(start+pieceSize, totalSize)) {
+			fileReport := affected[file.content.Index]
+			if fileReport == nil {
+				fileReport = &FileVerification{File: file.content}
+				affected[file.content.Index] = fileReport
+			}
+			if result == pieceVerifyCorrupt {
+				fileReport.CorruptPieces++
+			} else {
+				fileReport.MissingPieces++
+			}
+		}
+	}
+	for _, file := range files {
+		if fileReport := affected[file.content.Index]; fileReport != nil {
+			report.AffectedFiles = append(report.AffectedFiles, *fileReport)
+		}
+	}
+	return
+}
+
+func sha1Sum(data []byte) []byte {
+	sum := sha1.Sum(data)
+	return sum[:]
+}
+
+// pieceFiles returns the non empty files overlapping the [start, end[ data range.
+func pieceFiles(files []verifiedFile, start, end int64) []verifiedFile {
+	first := sort.Search(len(files), func(i int) bool {
+		return files[i].offset+files[i].content.Size > start
+	})
+	last := first
+	for last < len(files) && files[last].offset < end {
+		last++
+	}
+	overlapping := make([]verifiedFile, 0, last-first)
+	for _, file := range files[first:last] {
+		if file.content.Size > 0 {
+			overlapping = append(overlapping, file)
+		}
+	}
+	return overlapping
+}
+
+// readPiece fills data with the torrent data starting at start.
+func readPiece(fsys fs.FS, files []verifiedFile, start int64, data []byte) (err error) {
+	for _, file := range pieceFiles(files, start, start+int64(len(data))) {
+		fileStart := max(start, file.offset)
+		fileEnd := min(start+int64(len(data)), file.offset+file.content.Size)
+		if err = readFileAt(fsys, file.content.Name, data[fileStart-start:fileEnd-start], fileStart-file.offset); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func readFileAt(fsys fs.FS, name string, data []byte, offset int64) (err error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	switch typed := file.(type) {
+	case io.ReaderAt:
+		var read int
+		if read, err = typed.ReadAt(data, offset); read == len(data) {
+			// io.EOF is allowed when reading up to the end of the file
+			err = nil
+		}
+	case io.Seeker:
+		if _, err = typed.Seek(offset, io.SeekStart); err == nil {
+			_, err = io.ReadFull(file, data)
+		}
+	default:
+		err = errors.New("file does not support random access")
+	}
+	return
+}
@@ -0,0 +1,85 @@
+package qbtapi
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+)
+
+func TestVerifyPieces(t *testing.T) {
+	// 3 files of 10, 0 and 15 bytes with 8 bytes pieces: 4 pieces, piece 1 spanning a.bin and c.bin
+	data := []byte("0123456789abcdefghijklmno")
+	const pieceSize = 8
+	var hashes []string
+	for start := 0; start < len(data); start += pieceSize {
+		sum := sha1.Sum(data[start:min(start+pieceSize, len(data))])
+		hashes = append(hashes, hex.EncodeToString(sum[:]))
+	}
+	contents := []TorrentContent{
+		{Index: 2, Name: "t/c.bin", Size: 15},
+		{Index: 0, Name: "t/a.bin", Size: 10},
+		{Index: 1, Name: "t/empty", Size: 0},
+	}
+	fsys := fstest.MapFS{
+		"t/a.bin": {Data: data[:10]},
+		"t/empty": {Data: nil},
+		"t/c.bin": {Data: data[10:]},
+	}
+	ctx := context.Background()
+
+	// ── sane data ───────────────────────────────────────────
+	var progress atomic.Int32
+	report, err := VerifyPieces(ctx, fsys, contents, pieceSize, hashes, &PieceVerifyOptions{
+		Concurrency: 3,
+		Progress:    func(verified, total int) { progress.Add(1) },
+	})
+	if err != nil {
+		t.Fatalf("VerifyPieces: %v", err)
+	}
+	if !report.OK() || report.Pieces != 4 || progress.Load() != 4 {
+		t.Errorf("unexpected report: %+v (%d progress calls)", report, progress.Load())
+	}
+
+	// ── corrupt and missing data ────────────────────────────
+	corrupted := []byte(string(data[10:]))
+	corrupted[12] = 'X' // piece 2 (bytes 16-23 of the torrent)
+	fsys["t/c.bin"] = &fstest.MapFile{Data: corrupted}
+	report, err = VerifyPieces(ctx, fsys, contents, pieceSize, hashes, nil)
+	if err != nil {
+		t.Fatalf("VerifyPieces: %v", err)
+	}
+	if !slices.Equal(report.CorruptPieces, []int{2}) || len(report.MissingPieces) != 0 {
+		t.Errorf("unexpected bad pieces: %+v", report)
+	}
+	if len(report.AffectedFiles) != 1 || report.AffectedFiles[0].File.Name != "t/c.bin" || report.AffectedFiles[0].CorruptPieces != 1 {
+		t.Errorf("unexpected affected files: %+v", report.AffectedFiles)
+	}
+	delete(fsys, "t/a.bin")
+	if report, err = VerifyPieces(ctx, fsys, contents, pieceSize, hashes, nil); err != nil {
+		t.Fatalf("VerifyPieces: %v", err)
+	}
+	if !slices.Equal(report.MissingPieces, []int{0, 1}) || !slices.Equal(report.CorruptPieces, []int{2}) {
+		t.Errorf("unexpected bad pieces: %+v", report)
+	}
+	if len(report.AffectedFiles) != 2 || report.AffectedFiles[0].File.Name != "t/a.bin" || report.AffectedFiles[0].MissingPieces != 2 ||
+		report.AffectedFiles[1].MissingPieces != 1 || report.AffectedFiles[1].CorruptPieces != 1 {
+		t.Errorf("unexpected affected files: %+v", report.AffectedFiles)
+	}
+
+	// ── invalid inputs ──────────────────────────────────────
+	if _, err = VerifyPieces(ctx, fsys, contents, pieceSize, hashes[:3], nil); err == nil {
+		t.Error("expected an error for a pieces count mismatch")
+	}
+	if _, err = VerifyPieces(ctx, fsys, contents, pieceSize, []string{"aa", "bb", "cc", "dd"}, nil); err == nil {
+		t.Error("expected an error for non SHA-1 hashes")
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err = VerifyPieces(canceled, fsys, contents, pieceSize, hashes, nil); err == nil {
+		t.Error("expected an error for a canceled context")
+	}
+}
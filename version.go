@@ -0,0 +1,140 @@
+package qbtapi
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+	API version negotiation
+*/
+
+// APIVersion is a parsed Web API version (eg "2.11.3").
+type APIVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseAPIVersion parses a Web API version as returned by GetAPIVersion(). Missing minor and patch
+// components default to 0.
+func ParseAPIVersion(version string) (parsed APIVersion, err error) {
+	parts := strings.Split(strings.TrimSpace(version), ".")
+	if len(parts) > 3 {
+		err = fmt.Errorf("invalid API version %q: too many components", version)
+		return
+	}
+	components := []*int{&parsed.Major, &parsed.Minor, &parsed.Patch}
+	for index, part := range parts {
+		if *components[index], err = strconv.Atoi(part); err != nil || *components[index] < 0 {
+			err = fmt.Errorf("invalid API version %q: invalid component %q", version, part)
+			return
+		}
+	}
+	return
+}
+
+func (av APIVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", av.Major, av.Minor, av.Patch)
+}
+
+// Compare returns -1, 0 or 1 if av is lower, equal or greater than other.
+func (av APIVersion) Compare(other APIVersion) int {
+	for _, diff := range [...]int{av.Major - other.Major, av.Minor - other.Minor, av.Patch - other.Patch} {
+		switch {
+		case diff < 0:
+			return -1
+		case diff > 0:
+			return 1
+		}
+	}
+	return 0
+}
+
+// AtLeast returns true if av is greater than or equal to other.
+func (av APIVersion) AtLeast(other APIVersion) bool {
+	return av.Compare(other) >= 0
+}
+
+// Supports returns true if a server with this API version has feature.
+func (av APIVersion) Supports(feature APIFeature) bool {
+	since, known := apiFeaturesSince[feature]
+	return known && av.AtLeast(since)
+}
+
+// APIFeature represents an API capability which is not available on all server versions.
+type APIFeature uint8
+
+const (
+	APIFeatureStartStop          APIFeature = iota // torrents/start and torrents/stop endpoints, "stopped" and "running" states and parameters (pause/resume, "paused" and "resumed" before)
+	APIFeatureAddContentLayout                     // contentLayout parameter when adding torrents (root_folder before)
+	APIFeatureTorrentPrivateFlag                   // private flag within torrents listings
+	APIFeatureSetTags                              // torrents/setTags endpoint
+)
+
+var apiFeaturesSince = map[APIFeature]APIVersion{
+	APIFeatureStartStop:          {2, 11, 0}, // qBittorrent 5.0.0
+	APIFeatureAddContentLayout:   {2, 7, 0},  // qBittorrent 4.3.2
+	APIFeatureTorrentPrivateFlag: {2, 11, 0}, // qBittorrent 5.0.0
+	APIFeatureSetTags:            {2, 11, 4}, // qBittorrent 5.1.0
+}
+
+func (af APIFeature) String() string {
+	switch af {
+	case APIFeatureStartStop:
+		return "StartStop"
+	case APIFeatureAddContentLayout:
+		return "AddContentLayout"
+	case APIFeatureTorrentPrivateFlag:
+		return "TorrentPrivateFlag"
+	case APIFeatureSetTags:
+		return "SetTags"
+	default:
+		return "Unknown"
+	}
+}
+
+// ServerAPIVersion returns the API version of the server. It is fetched with GetAPIVersion() on first use and
+// cached until the next login (which happens when the session expires, for example after a server restart).
+func (c *Client) ServerAPIVersion(ctx context.Context) (version APIVersion, err error) {
+	c.apiVersionAccess.Lock()
+	cached := c.apiVersion
+	c.apiVersionAccess.Unlock()
+	if cached != nil {
+		return *cached, nil
+	}
+	// not holding the lock while fetching as an automatic login resets the cache
+	rawVersion, err := c.GetAPIVersion(ctx)
+	if err != nil {
+		err = fmt.Errorf("getting API version failed: %w", err)
+		return
+	}
+	if version, err = ParseAPIVersion(rawVersion); err != nil {
+		return
+	}
+	c.apiVersionAccess.Lock()
+	c.apiVersion = &version
+	c.apiVersionAccess.Unlock()
+	return
+}
+
+// Supports returns true if the server has feature. See ServerAPIVersion().
+func (c *Client) Supports(ctx context.Context, feature APIFeature) (supported bool, err error) {
+	version, err := c.ServerAPIVersion(ctx)
+	if err != nil {
+		return
+	}
+	supported = version.Supports(feature)
+	return
+}
+
+// resetAPIVersion drops the cached server API version.
+func (c *Client) resetAPIVersion() {
+	c.apiVersionAccess.Lock()
+	if !c.apiVersionPinned {
+		c.apiVersion = nil
+	}
+	c.apiVersionAccess.Unlock()
+}
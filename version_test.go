@@ -0,0 +1,170 @@
+package qbtapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func TestParseAPIVersion(t *testing.T) {
+	for _, tc := range []struct {
+		raw      string
+		expected APIVersion
+		valid    bool
+	}{
+		{"2.11.3", APIVersion{2, 11, 3}, true},
+		{"2.8\n", APIVersion{2, 8, 0}, true},
+		{"2", APIVersion{2, 0, 0}, true},
+		{"2.11.3.1", APIVersion{}, false},
+		{"v2.11", APIVersion{}, false},
+		{"2.-1", APIVersion{}, false},
+		{"", APIVersion{}, false},
+	} {
+		version, err := ParseAPIVersion(tc.raw)
+		if (err == nil) != tc.valid {
+			t.Errorf("%q: expected valid=%v, got %v", tc.raw, tc.valid, err)
+			continue
+		}
+		if tc.valid && version != tc.expected {
+			t.Errorf("%q: expected %v, got %v", tc.raw, tc.expected, version)
+		}
+	}
+	for _, tc := range []struct {
+		a, b     APIVersion
+		expected int
+	}{
+		{APIVersion{2, 11, 3}, APIVersion{2, 11, 3}, 0},
+		{APIVersion{2, 11, 3}, APIVersion{2, 11, 0}, 1},
+		{APIVersion{2, 9, 3}, APIVersion{2, 11, 0}, -1},
+		{APIVersion{3, 0, 0}, APIVersion{2, 11, 3}, 1},
+	} {
+		if got := tc.a.Compare(tc.b); got != tc.expected {
+			t.Errorf("%v.Compare(%v): expected %d, got %d", tc.a, tc.b, tc.expected, got)
+		}
+	}
+	if v := (APIVersion{2, 8, 3}); v.Supports(APIFeatureStartStop) || !v.Supports(APIFeatureAddContentLayout) {
+		t.Errorf("unexpected features for %v", v)
+	}
+	if (APIVersion{9, 0, 0}).Supports(APIFeature(255)) {
+		t.Error("unknown features should not be supported")
+	}
+}
+
+func TestAPIVersionRouting(t *testing.T) {
+	// ── fake qBittorrent 4.6 instance ───────────────────────
+	var (
+		access        sync.Mutex
+		versionCalls  int
+		loggedIn      bool
+		calledMethods []string
+		filter        string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		access.Lock()
+		loggedIn = true
+		access.Unlock()
+		w.Header().Set(contentTypeHeader, contentTypeHeaderTextPlain)
+		_, _ = w.Write([]byte(expectedSuccessResponse))
+	})
+	mux.HandleFunc("/api/v2/app/webapiVersion", func(w http.ResponseWriter, r *http.Request) {
+		access.Lock()
+		defer access.Unlock()
+		if !loggedIn {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		versionCalls++
+		w.Header().Set(contentTypeHeader, contentTypeHeaderTextPlain)
+		_, _ = w.Write([]byte("2.9.3"))
+	})
+	for _, method := range []string{"pause", "resume", "stop", "start"} {
+		mux.HandleFunc("/api/v2/torrents/"+method, func(w http.ResponseWriter, r *http.Request) {
+			access.Lock()
+			calledMethods = append(calledMethods, method)
+			access.Unlock()
+		})
+	}
+	mux.HandleFunc("/api/v2/torrents/info", func(w http.ResponseWriter, r *http.Request) {
+		access.Lock()
+		filter = r.URL.Query().Get("filter")
+		access.Unlock()
+		w.Header().Set(contentTypeHeader, contentTypeHeaderJSON)
+		_, _ = w.Write([]byte(`[]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	endpoint, _ := url.Parse(server.URL)
+	c, err := New(endpoint, "user", "pass")
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	ctx := context.Background()
+
+	// ── version fetched once (after the auto login) ─────────
+	version, err := c.ServerAPIVersion(ctx)
+	if err != nil {
+		t.Fatalf("ServerAPIVersion: %v", err)
+	}
+	if version != (APIVersion{2, 9, 3}) {
+		t.Errorf("unexpected version: %v", version)
+	}
+	if supported, err := c.Supports(ctx, APIFeatureStartStop); err != nil || supported {
+		t.Errorf("expected StartStop to be unsupported, got %v (err: %v)", supported, err)
+	}
+
+	// ── legacy routing ──────────────────────────────────────
+	if err = c.StopTorrents(ctx, []string{"aaaa"}); err != nil {
+		t.Fatalf("StopTorrents: %v", err)
+	}
+	if err = c.StartTorrents(ctx, []string{"aaaa"}); err != nil {
+		t.Fatalf("StartTorrents: %v", err)
+	}
+	if _, err = c.GetTorrentList(ctx, &ListFilters{State: FilterStateStopped.Ptr()}); err != nil {
+		t.Fatalf("GetTorrentList: %v", err)
+	}
+	access.Lock()
+	if len(calledMethods) != 2 || calledMethods[0] != "pause" || calledMethods[1] != "resume" {
+		t.Errorf("expected legacy endpoints, got %v", calledMethods)
+	}
+	if filter != "paused" {
+		t.Errorf("expected legacy filter, got %q", filter)
+	}
+	if versionCalls != 1 {
+		t.Errorf("expected the version to be fetched once, got %d calls", versionCalls)
+	}
+	access.Unlock()
+
+	// ── cache reset on login ────────────────────────────────
+	if err = c.Login(ctx); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if _, err = c.ServerAPIVersion(ctx); err != nil {
+		t.Fatalf("ServerAPIVersion: %v", err)
+	}
+	access.Lock()
+	if versionCalls != 2 {
+		t.Errorf("expected the version to be fetched again after login, got %d calls", versionCalls)
+	}
+	access.Unlock()
+
+	// ── pinned version ──────────────────────────────────────
+	pinned, err := New(endpoint, "user", "pass", WithAPIVersion(APIVersion{2, 11, 3}))
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	if err = pinned.Login(ctx); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if err = pinned.StopTorrents(ctx, []string{"aaaa"}); err != nil {
+		t.Fatalf("StopTorrents: %v", err)
+	}
+	access.Lock()
+	if calledMethods[len(calledMethods)-1] != "stop" || versionCalls != 2 {
+		t.Errorf("expected the pinned version to be used, got %v (%d version calls)", calledMethods, versionCalls)
+	}
+	access.Unlock()
+}